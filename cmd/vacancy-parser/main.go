@@ -1,27 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 
-	"runtime"
-
-	"sync"
 	"vacancy-parser/internal/app/apiserver"
-
+	"vacancy-parser/internal/app/crawler"
+	"vacancy-parser/internal/app/model"
 	"vacancy-parser/internal/app/store"
 
-	"vacancy-parser/internal/app/parser"
-
 	"github.com/BurntSushi/toml"
 )
 
 // Создать папку в internal/lib parser
 // Создать логику для парсинга habr vacancy
-// Перенести всю логику парсинга в модуль store в методы для парсинга
-
-const page = 20
 
 var (
 	configPath string
@@ -39,7 +34,21 @@ func main() {
 		log.Fatal(err)
 	}
 
-	startParser(config, "JavaScript")
+	switch flag.Arg(0) {
+	case "":
+	case "reparse":
+		if err := reparse(config); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "grant-admin":
+		if err := grantAdmin(config); err != nil {
+			log.Fatal(err)
+		}
+		return
+	default:
+		log.Fatalf("unknown command %q", flag.Arg(0))
+	}
 
 	s := apiserver.New(config)
 	if err := s.Start(); err != nil {
@@ -47,54 +56,47 @@ func main() {
 	}
 }
 
-func startParser(config *apiserver.Config, language string) {
-	store := store.New(config.Store)
-
-	err := store.Open()
-	if err != nil {
-		log.Fatal(err)
+// reparse runs the current parser over the archived vacancy pages.
+func reparse(config *apiserver.Config) error {
+	st := store.New(config.Store)
+	if err := st.Open(); err != nil {
+		return err
 	}
+	defer st.Close()
 
-	defer store.Close()
-	var repo = store.Vacancy()
-
-	deleteAllVacancy(repo)
-
-	var URLSlice = parser.GetURLS(page, language)
-	for i := 0; i < len(URLSlice); i++ {
-		fmt.Println(URLSlice[i])
+	c := crawler.New(config.Crawl, st, nil)
+	if err := c.WatchSelectors(context.Background()); err != nil {
+		return err
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(URLSlice))
-	sem := make(chan struct{}, runtime.GOMAXPROCS(12)) // Использовать все доступные ядра
-	var mu sync.Mutex
-	for i := 0; i < len(URLSlice); i++ {
-		go func(i int) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() {
-				<-sem
-			}()
-			vacancyInfo := parser.GetInfoFromUrl(URLSlice[i], language)
-			if vacancyInfo == nil {
-				fmt.Println("Skip url:", URLSlice[i])
-				return
-			}
-			mu.Lock()
-			_, err = repo.InsertVacancy(vacancyInfo)
-			mu.Unlock()
-			if err != nil {
-				log.Fatal(err)
-			}
-		}(i)
+	res, err := c.Reparse(context.Background())
+	if res != nil {
+		fmt.Printf("Reparsed vacancies: %+v\n", *res)
 	}
-	wg.Wait()
+
+	return err
 }
 
-func deleteAllVacancy(repo *store.VacancyRepository) {
-	_, err := repo.DeleteAllVacancy()
-	if err != nil {
-		log.Fatal(err)
+// grantAdmin gives the admin role to the registered users among the
+// configured admin emails.
+func grantAdmin(config *apiserver.Config) error {
+	st := store.New(config.Store)
+	if err := st.Open(); err != nil {
+		return err
+	}
+	defer st.Close()
+
+	for _, email := range config.Auth.AdminEmails {
+		_, err := st.User().GrantRole(email, model.RoleAdmin)
+		if errors.Is(err, store.ErrRecordNotFound) {
+			fmt.Println("Not registered, skipped:", email)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("Granted admin:", email)
 	}
+
+	return nil
 }
@@ -0,0 +1,180 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"vacancy-parser/internal/app/auth"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type apiKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	Quota *int64 `json:"quota"`
+}
+
+type createdAPIKey struct {
+	Key    string        `json:"key"`
+	APIKey *model.APIKey `json:"apiKey"`
+}
+
+// CreateAPIKey issues a key owned by the caller. The plaintext key is only
+// returned here, the store keeps its hash.
+func (s *APIServer) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	claims := claimsFromContext(r.Context())
+
+	var req apiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = model.APIKeyScopeRead
+	}
+	if req.Scope == model.APIKeyScopeReadWrite && !model.HasPermission(claims.Roles, model.PermVacanciesWrite) {
+		w.WriteHeader(http.StatusForbidden)
+		res.Error = "read-write keys require the editor role"
+		return
+	}
+	if req.Scope == model.APIKeyScopeWebhooks && !model.HasPermission(claims.Roles, model.PermWebhooksManage) {
+		w.WriteHeader(http.StatusForbidden)
+		res.Error = "webhooks keys require the editor role"
+		return
+	}
+
+	ownerID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot generate api key", err)
+		res.Error = err.Error()
+		return
+	}
+
+	apiKey := &model.APIKey{
+		OwnerID: ownerID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		Hash:    hash,
+		Scope:   req.Scope,
+	}
+	apiKey.Quota, err = s.apiKeyQuota(req.Quota, model.HasPermission(claims.Roles, model.PermUsersManage))
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		res.Error = err.Error()
+		return
+	}
+
+	if err := s.store.APIKey().Create(apiKey); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot create api key", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = createdAPIKey{Key: key, APIKey: apiKey}
+	w.WriteHeader(http.StatusOK)
+	log.Println("created api key", apiKey.Prefix, claims.Email)
+}
+
+// apiKeyQuota returns the quota of a new key. Users may only lower the
+// configured quota, admins may set any, including 0 for unlimited.
+func (s *APIServer) apiKeyQuota(requested *int64, admin bool) (int64, error) {
+	limit := s.config.Auth.APIKeyQuota
+	if requested == nil {
+		return limit, nil
+	}
+
+	quota := *requested
+	switch {
+	case admin && quota >= 0:
+		return quota, nil
+	case quota < 1:
+		return 0, fmt.Errorf("quota must be at least 1")
+	case limit > 0 && quota > limit:
+		return 0, fmt.Errorf("quota must not exceed %d", limit)
+	}
+
+	return quota, nil
+}
+
+func (s *APIServer) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	ownerID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	keys, err := s.store.APIKey().FindByOwner(ownerID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find api keys", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = keys
+	w.WriteHeader(http.StatusOK)
+}
+
+// RevokeAPIKey revokes one of the caller's keys, admins may revoke any key.
+func (s *APIServer) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	claims := claimsFromContext(r.Context())
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		res.Error = "api key not found"
+		return
+	}
+
+	var ownerID *primitive.ObjectID
+	if !model.HasPermission(claims.Roles, model.PermUsersManage) {
+		owner, ok := userIDFromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			res.Error = errNotAuthenticated.Error()
+			return
+		}
+		ownerID = &owner
+	}
+
+	if err := s.store.APIKey().Revoke(id, ownerID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot revoke api key", err)
+		res.Error = err.Error()
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.Println("revoked api key", id.Hex())
+}
@@ -1,24 +1,44 @@
 package apiserver
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
+	"sync"
+	"vacancy-parser/internal/app/auth"
+	"vacancy-parser/internal/app/crawler"
+	"vacancy-parser/internal/app/events"
 	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/notifier"
+	"vacancy-parser/internal/app/scheduler"
 	"vacancy-parser/internal/app/store"
+	"vacancy-parser/internal/app/telegram"
+	"vacancy-parser/internal/app/webhook"
 
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type APIServer struct {
-	config *Config
-	logger slog.Logger
-	router *mux.Router
-	store  *store.Store
+	config    *Config
+	logger    slog.Logger
+	router    *mux.Router
+	store     *store.Store
+	tokens    *auth.Manager
+	bus       *events.Bus
+	crawler   *crawler.Crawler
+	notifier  *notifier.Notifier
+	webhooks  *webhook.Dispatcher
+	scheduler *scheduler.Scheduler
 }
 
 type Response struct {
@@ -33,16 +53,59 @@ func New(config *Config) *APIServer {
 		logger: *slog.New(slog.NewJSONHandler(os.Stdout,
 			&slog.HandlerOptions{Level: slog.LevelDebug})),
 		router: mux.NewRouter(),
+		bus:    events.NewBus(),
 	}
 }
 
 func (s *APIServer) Start() error {
+	tokens, err := auth.NewManager(s.config.Auth)
+	if err != nil {
+		return err
+	}
+	s.tokens = tokens
+
+	if err := s.config.Crawl.Validate(); err != nil {
+		return err
+	}
+
 	s.configureRouter()
 
 	if err := s.configureStore(); err != nil {
 		return err
 	}
 
+	s.notifier = notifier.New(s.config.Notifier, s.store, notifier.NewSMTPMailer(s.config.Notifier), []byte(s.config.Auth.Secret))
+	if s.config.Notifier.Enabled {
+		go s.notifier.Run(context.Background())
+	}
+
+	s.webhooks = webhook.NewDispatcher(s.config.Webhook, s.store)
+	if s.config.Webhook.Enabled {
+		go s.webhooks.Run(context.Background(), s.bus)
+	}
+
+	if s.config.Telegram.Enabled {
+		bot := telegram.NewBot(s.config.Telegram, s.store)
+		s.crawler.AddMatchNotifier(bot)
+		go bot.Run(context.Background())
+	}
+
+	if s.config.Scheduler.Enabled {
+		s.scheduler, err = scheduler.New(s.config.Scheduler, s.crawler, s.store)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Resumed jobs must find the notifiers in place, and the scheduler
+	// adopts the ones it started.
+	if err := s.crawler.Resume(); err != nil {
+		return err
+	}
+	if s.scheduler != nil {
+		go s.scheduler.Run(context.Background())
+	}
+
 	s.logger.Info("starting server")
 	s.logger.Info("port listening", slog.String("port", s.config.BindAddr))
 	return http.ListenAndServe(s.config.BindAddr, s.router)
@@ -51,7 +114,7 @@ func (s *APIServer) Start() error {
 func enableCors(w *http.ResponseWriter) {
 	(*w).Header().Set("Access-Control-Allow-Origin", "*")
 	(*w).Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	(*w).Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 }
 
 func (s *APIServer) configureRouter() {
@@ -64,19 +127,61 @@ func (s *APIServer) configureRouter() {
 			next.ServeHTTP(w, r)
 		})
 	})
+	s.router.Use(s.identify)
 
 	s.router.HandleFunc("/hello", s.handleHello())
+	s.router.HandleFunc("/auth/login", s.Login).Methods(http.MethodPost)
+	s.router.HandleFunc("/auth/refresh", s.Refresh).Methods(http.MethodPost)
 	s.router.HandleFunc("/user", s.CreateUser).Methods(http.MethodPost)
-	s.router.HandleFunc("/user/{email}", s.GetUserByEmail).Methods(http.MethodGet)
-	s.router.HandleFunc("/users", s.GetAllUsers).Methods(http.MethodGet)
-	s.router.HandleFunc("/user/{email}", s.UpdateUserByEmail).Methods(http.MethodPut)
-	s.router.HandleFunc("/user/{email}", s.DeleteUserByEmail).Methods(http.MethodDelete)
-	s.router.HandleFunc("/users", s.DeleteAllUsers).Methods(http.MethodDelete)
-	s.router.HandleFunc("/vacancy", s.InsertVacancy).Methods(http.MethodPost)
+	s.router.HandleFunc(notifier.UnsubscribePath, s.UnsubscribeDigest).Methods(http.MethodGet, http.MethodPost)
 	s.router.HandleFunc("/vacancies/count/", s.GetAllVacanciesCount).Methods(http.MethodGet)
 	s.router.HandleFunc("/vacancies/hardSkills/", s.GetAllHardSkills).Methods(http.MethodGet)
 	s.router.HandleFunc("/vacancies/{page:[0-9]+}/{limit:[0-9]+}/", s.GetVacancies).Methods(http.MethodGet)
+	s.router.HandleFunc("/vacancies/stream", s.StreamVacancies).Methods(http.MethodGet)
 	s.router.HandleFunc("/vacancies/", s.GetAllVacancies).Methods(http.MethodGet)
+
+	private := s.router.NewRoute().Subrouter()
+	private.Use(s.authenticate)
+	private.HandleFunc("/auth/logout", s.requireUserToken(s.Logout)).Methods(http.MethodPost)
+	private.HandleFunc("/apikeys", s.requireUserToken(s.CreateAPIKey)).Methods(http.MethodPost)
+	private.HandleFunc("/apikeys", s.requireUserToken(s.GetAPIKeys)).Methods(http.MethodGet)
+	private.HandleFunc("/apikeys/{id}", s.requireUserToken(s.RevokeAPIKey)).Methods(http.MethodDelete)
+	private.HandleFunc("/user/{email}", s.requireSelfOrPermission(model.PermUsersManage, s.GetUserByEmail)).Methods(http.MethodGet)
+	private.HandleFunc("/user/{email}", s.requireSelfOrPermission(model.PermUsersManage, s.UpdateUserByEmail)).Methods(http.MethodPut)
+	private.HandleFunc("/user/{email}", s.requirePermission(model.PermUsersManage, s.DeleteUserByEmail)).Methods(http.MethodDelete)
+	private.HandleFunc("/user/{email}/roles", s.requirePermission(model.PermUsersManage, s.UpdateUserRoles)).Methods(http.MethodPut)
+	private.HandleFunc("/users", s.requirePermission(model.PermUsersManage, s.GetAllUsers)).Methods(http.MethodGet)
+	private.HandleFunc("/users", s.requirePermission(model.PermUsersManage, s.DeleteAllUsers)).Methods(http.MethodDelete)
+	private.HandleFunc("/vacancy", s.requirePermission(model.PermVacanciesManage, s.InsertVacancy)).Methods(http.MethodPost)
+	private.HandleFunc("/vacancies/bulk", s.requirePermission(model.PermVacanciesWrite, s.InsertVacanciesBulk)).Methods(http.MethodPost)
+	private.HandleFunc("/searches", s.CreateSavedSearch).Methods(http.MethodPost)
+	private.HandleFunc("/searches", s.GetSavedSearches).Methods(http.MethodGet)
+	private.HandleFunc("/searches/{id}", s.GetSavedSearch).Methods(http.MethodGet)
+	private.HandleFunc("/searches/{id}", s.UpdateSavedSearch).Methods(http.MethodPut)
+	private.HandleFunc("/searches/{id}", s.DeleteSavedSearch).Methods(http.MethodDelete)
+	private.HandleFunc("/inbox", s.GetInbox).Methods(http.MethodGet)
+	private.HandleFunc("/inbox/read", s.MarkInboxRead).Methods(http.MethodPost)
+	private.HandleFunc("/inbox/{id}/read", s.MarkInboxRead).Methods(http.MethodPost)
+	private.HandleFunc("/digest/preview", s.PreviewDigest).Methods(http.MethodGet)
+	private.HandleFunc("/digest/settings", s.UpdateDigestSettings).Methods(http.MethodPut)
+	private.HandleFunc("/telegram/link", s.requireUserToken(s.CreateTelegramLinkCode)).Methods(http.MethodPost)
+	private.HandleFunc("/applications", s.CreateApplication).Methods(http.MethodPost)
+	private.HandleFunc("/applications", s.GetApplications).Methods(http.MethodGet)
+	private.HandleFunc("/applications/{id}", s.GetApplication).Methods(http.MethodGet)
+	private.HandleFunc("/applications/{id}", s.UpdateApplication).Methods(http.MethodPut)
+	private.HandleFunc("/applications/{id}", s.DeleteApplication).Methods(http.MethodDelete)
+	private.HandleFunc("/webhooks", s.requirePermission(model.PermWebhooksManage, s.CreateWebhook)).Methods(http.MethodPost)
+	private.HandleFunc("/webhooks", s.requirePermission(model.PermWebhooksManage, s.GetWebhooks)).Methods(http.MethodGet)
+	private.HandleFunc("/webhooks/{id}", s.requirePermission(model.PermWebhooksManage, s.GetWebhook)).Methods(http.MethodGet)
+	private.HandleFunc("/webhooks/{id}", s.requirePermission(model.PermWebhooksManage, s.DeleteWebhook)).Methods(http.MethodDelete)
+	private.HandleFunc("/webhooks/{id}/deliveries", s.requirePermission(model.PermWebhooksManage, s.GetWebhookDeliveries)).Methods(http.MethodGet)
+	private.HandleFunc("/webhooks/{id}/deliveries/{deliveryID}/replay", s.requirePermission(model.PermWebhooksManage, s.ReplayWebhookDelivery)).Methods(http.MethodPost)
+	private.HandleFunc("/crawls", s.requirePermission(model.PermCrawlManage, s.StartCrawl)).Methods(http.MethodPost)
+	private.HandleFunc("/crawls", s.requirePermission(model.PermCrawlManage, s.GetCrawls)).Methods(http.MethodGet)
+	private.HandleFunc("/crawls/queries", s.requirePermission(model.PermCrawlManage, s.GetCrawlQueries)).Methods(http.MethodGet)
+	private.HandleFunc("/crawls/{id}", s.requirePermission(model.PermCrawlManage, s.GetCrawl)).Methods(http.MethodGet)
+	private.HandleFunc("/crawls/{id}", s.requirePermission(model.PermCrawlManage, s.CancelCrawl)).Methods(http.MethodDelete)
+	private.HandleFunc("/schedules", s.requirePermission(model.PermCrawlManage, s.GetSchedules)).Methods(http.MethodGet)
 }
 
 func (s *APIServer) configureStore() error {
@@ -87,8 +192,9 @@ func (s *APIServer) configureStore() error {
 	}
 
 	s.store = st
+	s.crawler = crawler.New(s.config.Crawl, st, s.bus)
 
-	return nil
+	return s.crawler.WatchSelectors(context.Background())
 }
 
 func (s *APIServer) handleHello() http.HandlerFunc {
@@ -111,17 +217,20 @@ func (s *APIServer) CreateUser(w http.ResponseWriter, r *http.Request) {
 		res.Error = err.Error()
 		return
 	}
+	user.ID = primitive.NilObjectID
+	user.PasswordHash = ""
+	user.Roles = nil
 
 	repo := s.store.User()
 	_, err = repo.CreateUser(&user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(errorStatus(err))
 		log.Println("cannot create user", err)
 		res.Error = err.Error()
 		return
 	}
 
-	res.Data = user.Email
+	res.Data = user
 	w.WriteHeader(http.StatusOK)
 	log.Println("created user", user.Email)
 }
@@ -137,7 +246,7 @@ func (s *APIServer) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 	repo := s.store.User()
 	user, err := repo.FindByEmail(email)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(errorStatus(err))
 		log.Println("cannot find user", err)
 		res.Error = err.Error()
 		return
@@ -177,7 +286,7 @@ func (s *APIServer) UpdateUserByEmail(w http.ResponseWriter, r *http.Request) {
 	repo := s.store.User()
 	user, err := repo.FindByEmail(email)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(errorStatus(err))
 		log.Println("cannot find user", err)
 		res.Error = err.Error()
 		return
@@ -192,10 +301,17 @@ func (s *APIServer) UpdateUserByEmail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user.Email = email
-	count, err := repo.UpdateUserByEmail(email, &updUser)
+	if updUser.Email != "" {
+		user.Email = updUser.Email
+	}
+	if updUser.Name != "" {
+		user.Name = updUser.Name
+	}
+	user.Password = updUser.Password
+
+	count, err := repo.UpdateUserByEmail(email, user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(errorStatus(err))
 		log.Println("cannot update user", err)
 		res.Error = err.Error()
 		return
@@ -206,6 +322,36 @@ func (s *APIServer) UpdateUserByEmail(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	log.Println("updated user", email)
 }
+func (s *APIServer) UpdateUserRoles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	email := mux.Vars(r)["email"]
+
+	var roles []string
+	err := json.NewDecoder(r.Body).Decode(&roles)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+
+	count, err := s.store.User().UpdateRoles(email, roles)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot update roles", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = count
+
+	w.WriteHeader(http.StatusOK)
+	log.Println("updated roles", email, roles)
+}
 func (s *APIServer) DeleteUserByEmail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
@@ -280,6 +426,88 @@ func (s *APIServer) InsertVacancy(w http.ResponseWriter, r *http.Request) {
 	log.Println("inserted vacancy")
 }
 
+type bulkLineError struct {
+	Line  int    `json:"line"`
+	Link  string `json:"link,omitempty"`
+	Error string `json:"error"`
+}
+
+type bulkInsertResult struct {
+	Received int `json:"received"`
+	store.BatchStats
+	Errors []bulkLineError `json:"errors,omitempty"`
+}
+
+// InsertVacanciesBulk accepts NDJSON, one vacancy per line, and upserts them in batches.
+func (s *APIServer) InsertVacanciesBulk(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	result := &bulkInsertResult{}
+	lines := make(map[*model.Vacancy]int)
+	var mu sync.Mutex
+
+	writer := s.store.Vacancy().NewBatchWriter(func(e store.BulkItemError) {
+		mu.Lock()
+		defer mu.Unlock()
+		result.Errors = append(result.Errors, bulkLineError{Line: lines[e.Vacancy], Link: e.Vacancy.Link, Error: e.Err.Error()})
+	})
+	writer.OnFlush(func(res *store.BulkResult) {
+		s.bus.PublishWritten(res.Created, res.Changed)
+	})
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		result.Received++
+
+		var vac model.Vacancy
+		if err := json.Unmarshal(data, &vac); err != nil {
+			mu.Lock()
+			result.Errors = append(result.Errors, bulkLineError{Line: line, Error: err.Error()})
+			mu.Unlock()
+			continue
+		}
+		if vac.Link == "" {
+			mu.Lock()
+			result.Errors = append(result.Errors, bulkLineError{Line: line, Error: "link is required"})
+			mu.Unlock()
+			continue
+		}
+
+		mu.Lock()
+		lines[&vac] = line
+		mu.Unlock()
+		writer.Input() <- &vac
+	}
+
+	result.BatchStats = writer.Close()
+	result.Failed = int64(len(result.Errors))
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Line < result.Errors[j].Line
+	})
+
+	if err := scanner.Err(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		res.Data = result
+		return
+	}
+
+	res.Data = result
+	w.WriteHeader(http.StatusOK)
+	log.Println("bulk inserted vacancies", result.Received)
+}
+
 func (s *APIServer) GetVacancies(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
@@ -296,9 +524,16 @@ func (s *APIServer) GetVacancies(w http.ResponseWriter, r *http.Request) {
 		log.Fatal(err)
 	}
 
+	filters, err := filtersFromQuery(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		res.Error = err.Error()
+		return
+	}
+
 	repo := s.store.Vacancy()
 
-	vacs, err := repo.GetVacancies(int64(page), int64(limit))
+	vacs, err := repo.GetVacancies(filters, int64(page), int64(limit))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Println("cannot find vacancies", err)
@@ -320,9 +555,16 @@ func (s *APIServer) GetAllVacancies(w http.ResponseWriter, r *http.Request) {
 	res := &Response{}
 	defer json.NewEncoder(w).Encode(res)
 
+	filters, err := filtersFromQuery(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		res.Error = err.Error()
+		return
+	}
+
 	repo := s.store.Vacancy()
 
-	vacs, err := repo.FindAllVacancy()
+	vacs, err := repo.FindAllVacancy(filters)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -343,9 +585,16 @@ func (s *APIServer) GetAllVacanciesCount(w http.ResponseWriter, r *http.Request)
 	res := &Response{}
 	defer json.NewEncoder(w).Encode(res)
 
+	filters, err := filtersFromQuery(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		res.Error = err.Error()
+		return
+	}
+
 	repo := s.store.Vacancy()
 
-	count, err := repo.GetAllVacanciesCount()
+	count, err := repo.GetAllVacanciesCount(filters)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Println("cannot find vacancies", err)
@@ -380,3 +629,18 @@ func (s *APIServer) GetAllHardSkills(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	log.Println("found skills")
 }
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, store.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrEmailTaken), errors.Is(err, store.ErrAlreadyTracked), errors.Is(err, crawler.ErrJobFinished):
+		return http.StatusConflict
+	case errors.Is(err, store.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
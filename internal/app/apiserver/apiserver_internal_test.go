@@ -1,11 +1,24 @@
 package apiserver
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
+	"vacancy-parser/internal/app/auth"
+	"vacancy-parser/internal/app/events"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/notifier"
+	"vacancy-parser/internal/app/store"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestAPIServer_HandleHello(t *testing.T) {
@@ -15,3 +28,179 @@ func TestAPIServer_HandleHello(t *testing.T) {
 	s.handleHello().ServeHTTP(rec, req)
 	assert.Equal(t, rec.Body.String(), "Hello")
 }
+
+func TestAPIServer_PrivateRoutesRequireToken(t *testing.T) {
+	s := New(NewConfig())
+	s.configureRouter()
+
+	for _, tc := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodDelete, "/users"},
+		{http.MethodGet, "/users"},
+		{http.MethodPost, "/vacancy"},
+		{http.MethodPost, "/auth/logout"},
+	} {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Basic Zm9vOmJhcg==")
+		s.router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, tc.method+" "+tc.path)
+	}
+}
+
+func TestAPIServer_RequirePermission(t *testing.T) {
+	s := New(NewConfig())
+	handler := s.requirePermission(model.PermUsersManage, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		roles []string
+		code  int
+	}{
+		{[]string{model.RoleUser}, http.StatusForbidden},
+		{[]string{model.RoleEditor}, http.StatusForbidden},
+		{[]string{model.RoleAdmin}, http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+		req = req.WithContext(context.WithValue(req.Context(), ctxKeyClaims, &auth.Claims{Roles: tc.roles}))
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.roles)
+	}
+}
+
+func TestAPIServer_APIKeyQuota(t *testing.T) {
+	s := New(NewConfig())
+	s.config.Auth.APIKeyQuota = 1000
+	quota := func(q int64) *int64 {
+		return &q
+	}
+
+	for _, tc := range []struct {
+		requested *int64
+		admin     bool
+		want      int64
+		err       bool
+	}{
+		{nil, false, 1000, false},
+		{quota(10), false, 10, false},
+		{quota(0), false, 0, true},
+		{quota(-1), false, 0, true},
+		{quota(5000), false, 0, true},
+		{quota(0), true, 0, false},
+		{quota(5000), true, 5000, false},
+		{quota(-1), true, 0, true},
+	} {
+		got, err := s.apiKeyQuota(tc.requested, tc.admin)
+		if tc.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+}
+
+func TestAPIServer_UnsubscribeDigestAsksFirst(t *testing.T) {
+	s := New(NewConfig())
+	s.config.Auth.Secret = "0123456789abcdef0123456789abcdef"
+	token := notifier.UnsubscribeToken([]byte(s.config.Auth.Secret), primitive.NewObjectID())
+
+	// GET only renders the confirmation, the store is not even opened.
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, notifier.UnsubscribePath+"?token="+url.QueryEscape(token), nil)
+	s.UnsubscribeDigest(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `method="post"`)
+	assert.Contains(t, rec.Body.String(), url.QueryEscape(token))
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, notifier.UnsubscribePath+"?token=forged", nil)
+	s.UnsubscribeDigest(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestFiltersFromQuery(t *testing.T) {
+	q, _ := url.ParseQuery("text=go&skills=Go,+gRPC&skills=Docker&remote=true&salaryFrom=300000&language=Go")
+	filters, err := filtersFromQuery(q)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Filters{
+		Text:         "go",
+		HardSkills:   []string{"Go", "gRPC", "Docker"},
+		MainLanguage: "Go",
+		Remote:       true,
+		SalaryFrom:   300000,
+	}, filters)
+
+	_, err = filtersFromQuery(url.Values{"salaryFrom": {"lots"}})
+	assert.ErrorIs(t, err, model.ErrValidation)
+}
+
+func TestAPIServer_StreamVacancies(t *testing.T) {
+	s := New(NewConfig())
+	s.bus.Publish(events.Event{Type: events.VacancyCreated, Vacancy: &model.Vacancy{Title: "Go developer", MainLanguage: "Go"}})
+	s.bus.Publish(events.Event{Type: events.VacancyCreated, Vacancy: &model.Vacancy{Title: "PHP developer", MainLanguage: "PHP"}})
+	s.bus.Publish(events.Event{Type: events.VacancyUpdated, Vacancy: &model.Vacancy{Title: "Senior Go developer", MainLanguage: "Go"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/vacancies/stream?language=go", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	s.StreamVacancies(rec, req)
+
+	body := rec.Body.String()
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Contains(t, body, "Go developer")
+	assert.NotContains(t, body, "PHP developer")
+
+	// Resuming after the first event replays only the update.
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "/vacancies/stream?language=go", nil)
+	first := strings.TrimPrefix(strings.Split(body, "\n")[2], "id: ")
+	req.Header.Set("Last-Event-ID", first)
+	s.StreamVacancies(rec, req)
+
+	assert.NotContains(t, rec.Body.String(), fmt.Sprintf("id: %s\n", first))
+	assert.Contains(t, rec.Body.String(), "event: vacancy.updated")
+}
+
+func TestAPIServer_CreateApplicationSnapshot(t *testing.T) {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		databaseURL = "mongodb://localhost:27017"
+	}
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown("applications", "vacancies")
+
+	s := New(NewConfig())
+	s.store = st
+	userID := primitive.NewObjectID()
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/applications", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), ctxKeyClaims, &auth.Claims{Subject: userID.Hex()}))
+		s.CreateApplication(rec, req)
+		return rec
+	}
+
+	// A link which is not stored needs the caller's snapshot of the vacancy.
+	rec := create(`{"vacancyLink": "https://hh.ru/vacancy/1"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = create(`{"vacancyLink": "https://hh.ru/vacancy/1", "vacancy": {"title": "Go developer"}, "stage": "applied"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	apps, err := st.Application().FindByUser(userID, "")
+	require.NoError(t, err)
+	require.Len(t, apps, 1)
+	assert.Equal(t, "Go developer", apps[0].Vacancy.Title)
+	assert.Equal(t, "https://hh.ru/vacancy/1", apps[0].Vacancy.Link)
+	assert.Equal(t, model.StageApplied, apps[0].Stage)
+	require.Len(t, apps[0].History, 1)
+	assert.Equal(t, model.StageApplied, apps[0].History[0].Stage)
+}
@@ -0,0 +1,210 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+)
+
+type createApplicationRequest struct {
+	VacancyLink string `json:"vacancyLink"`
+	// Vacancy is used as the snapshot when the link is not in the store,
+	// e.g. for a vacancy which was already closed.
+	Vacancy *model.Vacancy `json:"vacancy"`
+	Stage   string         `json:"stage"`
+	Notes   string         `json:"notes"`
+	At      time.Time      `json:"at"`
+}
+
+type updateApplicationRequest struct {
+	Stage *string   `json:"stage"`
+	Notes *string   `json:"notes"`
+	Note  string    `json:"note"`
+	At    time.Time `json:"at"`
+}
+
+func (s *APIServer) CreateApplication(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	var req createApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+	if req.Stage == "" {
+		req.Stage = model.StageSaved
+	}
+
+	vacancy, err := s.store.Vacancy().FindVacancyByLink(req.VacancyLink)
+	if err != nil {
+		if !errors.Is(err, store.ErrRecordNotFound) || req.Vacancy == nil {
+			w.WriteHeader(errorStatus(err))
+			log.Println("cannot find vacancy", err)
+			res.Error = err.Error()
+			return
+		}
+		vacancy = req.Vacancy
+		vacancy.Link = req.VacancyLink
+	}
+
+	app := &model.Application{
+		UserID:      userID,
+		VacancyLink: req.VacancyLink,
+		Vacancy:     *vacancy,
+		Notes:       req.Notes,
+	}
+	if err := app.MoveTo(req.Stage, req.At, ""); err != nil {
+		w.WriteHeader(errorStatus(err))
+		res.Error = err.Error()
+		return
+	}
+
+	if err := s.store.Application().Create(app); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot create application", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = app
+	w.WriteHeader(http.StatusOK)
+	log.Println("created application", app.ID.Hex())
+}
+
+// GetApplications lists the caller's applications, ?stage= filters by stage.
+func (s *APIServer) GetApplications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	apps, err := s.store.Application().FindByUser(userID, r.URL.Query().Get("stage"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find applications", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = apps
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) GetApplication(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	app, err := s.store.Application().Find(id, userID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find application", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = app
+	w.WriteHeader(http.StatusOK)
+}
+
+// UpdateApplication edits the notes and moves the application to another stage.
+func (s *APIServer) UpdateApplication(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	var req updateApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+
+	app, err := s.store.Application().Find(id, userID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find application", err)
+		res.Error = err.Error()
+		return
+	}
+
+	if req.Notes != nil {
+		app.Notes = *req.Notes
+	}
+	if req.Stage != nil && *req.Stage != app.Stage {
+		if err := app.MoveTo(*req.Stage, req.At, req.Note); err != nil {
+			w.WriteHeader(errorStatus(err))
+			res.Error = err.Error()
+			return
+		}
+	}
+
+	if err := s.store.Application().Update(app); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot update application", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = app
+	w.WriteHeader(http.StatusOK)
+	log.Println("updated application", id.Hex())
+}
+
+func (s *APIServer) DeleteApplication(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	if err := s.store.Application().Delete(id, userID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot delete application", err)
+		res.Error = err.Error()
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.Println("deleted application", id.Hex())
+}
@@ -0,0 +1,369 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"vacancy-parser/internal/app/auth"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ctxKey int
+
+const ctxKeyClaims ctxKey = iota
+
+var (
+	errNotAuthenticated   = errors.New("not authenticated")
+	errInvalidCredentials = errors.New("invalid email or password")
+	errForbidden          = errors.New("forbidden")
+)
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (s *APIServer) Login(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+
+	user, err := s.store.User().FindByEmail(req.Email)
+	if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find user", err)
+		res.Error = err.Error()
+		return
+	}
+	if user == nil || !user.ComparePassword(req.Password) {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Println("failed login", req.Email)
+		res.Error = errInvalidCredentials.Error()
+		return
+	}
+
+	pair, err := s.tokens.Issue(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot issue tokens", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = pair
+	w.WriteHeader(http.StatusOK)
+	log.Println("logged in", user.Email)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The used refresh
+// token is revoked, so each one can be exchanged only once, even by
+// concurrent requests.
+func (s *APIServer) Refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+
+	claims, err := s.verifyToken(req.RefreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Println("invalid refresh token", err)
+		res.Error = err.Error()
+		return
+	}
+
+	user, err := s.store.User().FindByID(claims.Subject)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Println("cannot find user", err)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	if err := s.store.Token().Consume(claims.ID, claims.ExpiresTime()); err != nil {
+		if errors.Is(err, store.ErrTokenReused) {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Println("refresh token reused", user.Email)
+			res.Error = auth.ErrInvalidToken.Error()
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot revoke token", err)
+		res.Error = err.Error()
+		return
+	}
+
+	pair, err := s.tokens.Issue(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot issue tokens", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = pair
+	w.WriteHeader(http.StatusOK)
+	log.Println("refreshed tokens", user.Email)
+}
+
+// Logout revokes the access token of the request and, when given, the refresh token.
+func (s *APIServer) Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	claims := claimsFromContext(r.Context())
+
+	var req refreshRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Println("invalid request body", err)
+			res.Error = err.Error()
+			return
+		}
+	}
+
+	if req.RefreshToken != "" {
+		refresh, err := s.tokens.Parse(req.RefreshToken, auth.TokenTypeRefresh)
+		if err == nil && refresh.Subject == claims.Subject {
+			if err := s.store.Token().Revoke(refresh.ID, refresh.ExpiresTime()); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				log.Println("cannot revoke token", err)
+				res.Error = err.Error()
+				return
+			}
+		}
+	}
+
+	if err := s.store.Token().Revoke(claims.ID, claims.ExpiresTime()); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot revoke token", err)
+		res.Error = err.Error()
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.Println("logged out", claims.Email)
+}
+
+// identify authenticates requests made with an API key, passed either in the
+// X-API-Key header or as a bearer token, and counts them against the key
+// quota. Requests without an API key pass through untouched.
+func (s *APIServer) identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if token, ok := bearerToken(r); key == "" && ok && auth.IsAPIKey(token) {
+			key = token
+		}
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := s.verifyAPIKey(key)
+		if err != nil {
+			if errors.Is(err, store.ErrQuotaExceeded) {
+				w.Header().Add("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(&Response{Error: err.Error()})
+				return
+			}
+			s.respondUnauthorized(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyClaims, claims)))
+	})
+}
+
+// authenticate rejects requests without a valid, not revoked access token,
+// unless identify has already authenticated them with an API key.
+func (s *APIServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claimsFromContext(r.Context()) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			s.respondUnauthorized(w, errNotAuthenticated)
+			return
+		}
+
+		claims, err := s.verifyToken(token, auth.TokenTypeAccess)
+		if err != nil {
+			s.respondUnauthorized(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyClaims, claims)))
+	})
+}
+
+// requireUserToken rejects API keys on routes which manage the account itself.
+func (s *APIServer) requireUserToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims := claimsFromContext(r.Context())
+		if claims == nil || claims.Type != auth.TokenTypeAccess {
+			s.respondForbidden(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// requirePermission lets the request through when the authenticated roles grant perm.
+// It must be used behind authenticate.
+func (s *APIServer) requirePermission(perm model.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims := claimsFromContext(r.Context())
+		if claims == nil || !model.HasPermission(claims.Roles, perm) {
+			s.respondForbidden(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// requireSelfOrPermission is requirePermission which also admits a user
+// acting on their own account, identified by the {email} route variable.
+func (s *APIServer) requireSelfOrPermission(perm model.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims := claimsFromContext(r.Context())
+		if claims == nil {
+			s.respondForbidden(w)
+			return
+		}
+
+		self := model.NormalizeEmail(mux.Vars(r)["email"]) == claims.Email
+		if !self && !model.HasPermission(claims.Roles, perm) {
+			s.respondForbidden(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+func (s *APIServer) verifyToken(token, tokenType string) (*auth.Claims, error) {
+	claims, err := s.tokens.Parse(token, tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.store.Token().IsRevoked(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, auth.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (s *APIServer) verifyAPIKey(key string) (*auth.Claims, error) {
+	apiKey, err := s.store.APIKey().FindActiveByHash(auth.HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	// The key acts for its owner, so it stops working once the owner is
+	// deleted and carries no more than the owner's current roles.
+	owner, err := s.store.User().FindByID(apiKey.OwnerID.Hex())
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if err := s.store.APIKey().Consume(apiKey, s.config.Auth.APIKeyQuotaWindow); err != nil {
+		return nil, err
+	}
+
+	return &auth.Claims{
+		ID:      apiKey.ID.Hex(),
+		Subject: apiKey.OwnerID.Hex(),
+		Roles:   apiKey.Roles(owner.Roles),
+		Type:    auth.TokenTypeAPIKey,
+	}, nil
+}
+
+func (s *APIServer) respondUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(&Response{Error: err.Error()})
+}
+
+func (s *APIServer) respondForbidden(w http.ResponseWriter) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(&Response{Error: errForbidden.Error()})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
+
+// userIDFromContext returns the id of the authenticated user, or of the API key owner.
+func userIDFromContext(ctx context.Context) (primitive.ObjectID, bool) {
+	claims := claimsFromContext(ctx)
+	if claims == nil {
+		return primitive.NilObjectID, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return primitive.NilObjectID, false
+	}
+
+	return id, true
+}
+
+func claimsFromContext(ctx context.Context) *auth.Claims {
+	claims, _ := ctx.Value(ctxKeyClaims).(*auth.Claims)
+	return claims
+}
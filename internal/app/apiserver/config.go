@@ -1,17 +1,37 @@
 package apiserver
 
-import "vacancy-parser/internal/app/store"
+import (
+	"vacancy-parser/internal/app/auth"
+	"vacancy-parser/internal/app/crawler"
+	"vacancy-parser/internal/app/notifier"
+	"vacancy-parser/internal/app/scheduler"
+	"vacancy-parser/internal/app/store"
+	"vacancy-parser/internal/app/telegram"
+	"vacancy-parser/internal/app/webhook"
+)
 
 type Config struct {
-	BindAddr string `toml:"bind_addr" json:"bind_addr"`
-	LogLevel string `toml:"log_level" json:"log_level"`
-	Store    *store.Config
+	BindAddr  string `toml:"bind_addr" json:"bind_addr"`
+	LogLevel  string `toml:"log_level" json:"log_level"`
+	Store     *store.Config
+	Auth      *auth.Config      `toml:"auth"`
+	Crawl     *crawler.Config   `toml:"crawl"`
+	Notifier  *notifier.Config  `toml:"notifier"`
+	Telegram  *telegram.Config  `toml:"telegram"`
+	Webhook   *webhook.Config   `toml:"webhook"`
+	Scheduler *scheduler.Config `toml:"scheduler"`
 }
 
 func NewConfig() *Config {
 	return &Config{
-		BindAddr: ":4040",
-		LogLevel: "debug",
-		Store:    store.NewConfig(),
+		BindAddr:  ":4040",
+		LogLevel:  "debug",
+		Store:     store.NewConfig(),
+		Auth:      auth.NewConfig(),
+		Crawl:     crawler.NewConfig(),
+		Notifier:  notifier.NewConfig(),
+		Telegram:  telegram.NewConfig(),
+		Webhook:   webhook.NewConfig(),
+		Scheduler: scheduler.NewConfig(),
 	}
 }
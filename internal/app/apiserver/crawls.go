@@ -0,0 +1,167 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultCrawlPages = 20
+	crawlJobsLimit    = 50
+)
+
+type crawlRequest struct {
+	Sources     []string           `json:"sources"`
+	Queries     []model.CrawlQuery `json:"queries"`
+	Pages       int                `json:"pages"`
+	Incremental bool               `json:"incremental"`
+}
+
+// StartCrawl creates a crawl job and runs it in the background. Sources
+// default to every registered source and pages to 20. A query given only by
+// name refers to a configured one.
+func (s *APIServer) StartCrawl(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	req := crawlRequest{Pages: defaultCrawlPages}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+	if len(req.Sources) == 0 {
+		req.Sources = s.crawler.Sources()
+	}
+
+	job := &model.CrawlJob{
+		Sources:     req.Sources,
+		Queries:     req.Queries,
+		Pages:       req.Pages,
+		Incremental: req.Incremental,
+	}
+	if userID, ok := userIDFromContext(r.Context()); ok {
+		job.CreatedBy = &userID
+	}
+
+	if err := s.crawler.Start(job); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot start crawl", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = job
+	w.WriteHeader(http.StatusAccepted)
+	log.Println("started crawl job", job.ID.Hex())
+}
+
+func (s *APIServer) GetCrawls(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	jobs, err := s.crawler.Jobs(crawlJobsLimit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find crawl jobs", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = jobs
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) GetCrawl(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		res.Error = "crawl job not found"
+		return
+	}
+
+	job, err := s.crawler.Job(id)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find crawl job", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = job
+	w.WriteHeader(http.StatusOK)
+}
+
+// CancelCrawl stops a running crawl job, keeping what it has written so far.
+func (s *APIServer) CancelCrawl(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		res.Error = "crawl job not found"
+		return
+	}
+
+	job, err := s.crawler.Cancel(id)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot cancel crawl job", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = job
+	w.WriteHeader(http.StatusOK)
+	log.Println("canceled crawl job", id.Hex())
+}
+
+// GetCrawlQueries returns the configured crawl queries.
+func (s *APIServer) GetCrawlQueries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	queries := s.crawler.Queries()
+	if queries == nil {
+		queries = []model.CrawlQuery{}
+	}
+
+	res.Data = queries
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetSchedules returns the last and next run times of the scheduled crawls.
+func (s *APIServer) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	schedules := []model.Schedule{}
+	if s.scheduler != nil {
+		schedules = s.scheduler.Schedules()
+	}
+
+	res.Data = schedules
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,124 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/notifier"
+)
+
+type digestSettingsRequest struct {
+	Frequency string `json:"frequency"`
+}
+
+// PreviewDigest renders the caller's next digest, HTML by default and plain
+// text with ?format=text. Nothing is sent or marked emailed.
+func (s *APIServer) PreviewDigest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		s.respondUnauthorized(w, errNotAuthenticated)
+		return
+	}
+
+	user, err := s.store.User().FindByID(userID.Hex())
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find user", err)
+		return
+	}
+
+	msg, _, err := s.notifier.Render(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot render digest", err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, msg.Text)
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, msg.HTML)
+}
+
+func (s *APIServer) UpdateDigestSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	var req digestSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+
+	if err := s.store.User().UpdateDigestFrequency(userID, req.Frequency); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot update digest settings", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = req
+	w.WriteHeader(http.StatusOK)
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<form method="post" action="?token={{.}}">
+<p>Stop receiving the vacancy digest?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// UnsubscribeDigest is the target of the link in every digest, it works
+// without login. GET only asks to confirm, as mail scanners follow links, and
+// POST unsubscribes, which also serves one-click unsubscribe from mail clients.
+func (s *APIServer) UnsubscribeDigest(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	userID, err := notifier.ParseUnsubscribeToken([]byte(s.config.Auth.Secret), token)
+	if err != nil {
+		w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "The unsubscribe link is invalid.\n")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Header().Add("Content-Type", "text/html; charset=utf-8")
+		if err := unsubscribePage.Execute(w, token); err != nil {
+			log.Println("cannot render unsubscribe page", err)
+		}
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+	if err := s.store.User().UpdateDigestFrequency(userID, model.DigestOff); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot unsubscribe", err)
+		io.WriteString(w, "Cannot unsubscribe, please try again later.\n")
+		return
+	}
+
+	log.Println("unsubscribed from digest", userID.Hex())
+	io.WriteString(w, "You have been unsubscribed from the vacancy digest.\n")
+}
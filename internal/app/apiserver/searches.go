@@ -0,0 +1,245 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type savedSearchRequest struct {
+	Name    string        `json:"name"`
+	Filters model.Filters `json:"filters"`
+}
+
+func (s *APIServer) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	var req savedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+
+	search := &model.SavedSearch{
+		UserID:  userID,
+		Name:    req.Name,
+		Filters: req.Filters,
+	}
+	if err := s.store.SavedSearch().Create(search); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot create saved search", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = search
+	w.WriteHeader(http.StatusOK)
+	log.Println("created saved search", search.ID.Hex())
+}
+
+func (s *APIServer) GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	searches, err := s.store.SavedSearch().FindByUser(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find saved searches", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = searches
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	search, err := s.store.SavedSearch().Find(id, userID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find saved search", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = search
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	var req savedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+
+	search := &model.SavedSearch{
+		ID:      id,
+		UserID:  userID,
+		Name:    req.Name,
+		Filters: req.Filters,
+	}
+	if err := s.store.SavedSearch().Update(search); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot update saved search", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = search
+	w.WriteHeader(http.StatusOK)
+	log.Println("updated saved search", id.Hex())
+}
+
+func (s *APIServer) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	if err := s.store.SavedSearch().Delete(id, userID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot delete saved search", err)
+		res.Error = err.Error()
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.Println("deleted saved search", id.Hex())
+}
+
+// GetInbox lists saved search matches of the caller, newest first.
+// ?unread=true limits the list to matches not marked read.
+func (s *APIServer) GetInbox(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	matches, err := s.store.Inbox().FindByUser(userID, r.URL.Query().Get("unread") == "true")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find inbox", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = matches
+	w.WriteHeader(http.StatusOK)
+}
+
+// MarkInboxRead marks the {id} match read, or the whole inbox without {id}.
+func (s *APIServer) MarkInboxRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	var id *primitive.ObjectID
+	if hex, ok := mux.Vars(r)["id"]; ok {
+		matchID, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			res.Error = "match not found"
+			return
+		}
+		id = &matchID
+	}
+
+	count, err := s.store.Inbox().MarkRead(userID, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot mark inbox read", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = count
+	w.WriteHeader(http.StatusOK)
+}
+
+// ownedResourceIDs returns the caller id and the {id} route variable, writing
+// the error response itself when either is missing or malformed.
+func (s *APIServer) ownedResourceIDs(w http.ResponseWriter, r *http.Request, res *Response) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		res.Error = "not found"
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	return userID, id, true
+}
@@ -0,0 +1,137 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"vacancy-parser/internal/app/events"
+	"vacancy-parser/internal/app/model"
+)
+
+const (
+	streamBuffer            = 256
+	streamKeepAliveInterval = 15 * time.Second
+	streamRetry             = 5 * time.Second
+)
+
+// StreamVacancies is a Server-Sent Events stream of vacancies as they are
+// created or updated, limited by the list endpoint filters. A client
+// reconnecting with Last-Event-ID first receives the events it missed, as far
+// as the bus still keeps them.
+func (s *APIServer) StreamVacancies(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&Response{Error: "streaming unsupported"})
+		return
+	}
+
+	filters, err := filtersFromQuery(r.URL.Query())
+	if err != nil {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(errorStatus(err))
+		json.NewEncoder(w).Encode(&Response{Error: err.Error()})
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	since, _ := strconv.ParseUint(lastID, 10, 64)
+
+	missed, ch, unsubscribe := s.bus.SubscribeSince(since, streamBuffer)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+	for _, e := range missed {
+		if err := writeVacancyEvent(w, e, filters); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := writeVacancyEvent(w, e, filters); err != nil {
+				log.Println("cannot write event", err)
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeVacancyEvent writes created and updated vacancies matching the filters
+// and skips every other event.
+func writeVacancyEvent(w http.ResponseWriter, e events.Event, filters model.Filters) error {
+	if e.Vacancy == nil || (e.Type != events.VacancyCreated && e.Type != events.VacancyUpdated) {
+		return nil
+	}
+	if !filters.Match(e.Vacancy) {
+		return nil
+	}
+
+	data, err := json.Marshal(e.Vacancy)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// filtersFromQuery reads vacancy filters from query parameters: text,
+// skills (comma separated or repeated), location, language, tag, remote and salaryFrom.
+func filtersFromQuery(q url.Values) (model.Filters, error) {
+	filters := model.Filters{
+		Text:         strings.TrimSpace(q.Get("text")),
+		Location:     strings.TrimSpace(q.Get("location")),
+		MainLanguage: strings.TrimSpace(q.Get("language")),
+		Tag:          strings.TrimSpace(q.Get("tag")),
+		Remote:       q.Get("remote") == "true",
+	}
+
+	for _, value := range q["skills"] {
+		for _, skill := range strings.Split(value, ",") {
+			if skill = strings.TrimSpace(skill); skill != "" {
+				filters.HardSkills = append(filters.HardSkills, skill)
+			}
+		}
+	}
+
+	if salary := q.Get("salaryFrom"); salary != "" {
+		from, err := strconv.ParseInt(salary, 10, 64)
+		if err != nil || from < 0 {
+			return model.Filters{}, fmt.Errorf("%w: salaryFrom must be a non-negative number", model.ErrValidation)
+		}
+		filters.SalaryFrom = from
+	}
+
+	return filters, nil
+}
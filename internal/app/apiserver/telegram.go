@@ -0,0 +1,54 @@
+package apiserver
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+const telegramLinkTTL = 15 * time.Minute
+
+type telegramLinkCode struct {
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreateTelegramLinkCode issues a one-time code the caller sends to the bot
+// as "/link CODE" to receive alerts in that chat.
+func (s *APIServer) CreateTelegramLinkCode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot generate link code", err)
+		res.Error = err.Error()
+		return
+	}
+	code := base32.StdEncoding.EncodeToString(b)
+	expires := time.Now().UTC().Add(telegramLinkTTL)
+
+	if err := s.store.User().SetTelegramLinkCode(userID, code, expires); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot set link code", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = telegramLinkCode{Code: code, Command: "/link " + code, ExpiresAt: expires}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,237 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"vacancy-parser/internal/app/events"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/webhook"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const webhookDeliveriesLimit = 100
+
+type webhookRequest struct {
+	URL     string         `json:"url"`
+	Events  []string       `json:"events"`
+	Filters *model.Filters `json:"filters"`
+}
+
+// CreateWebhook subscribes a URL to events. The response carries the secret
+// payload signatures are made with.
+func (s *APIServer) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("invalid request body", err)
+		res.Error = err.Error()
+		return
+	}
+	for _, e := range req.Events {
+		if !events.ValidType(e) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			res.Error = fmt.Errorf("%w: unknown event %q", model.ErrValidation, e).Error()
+			return
+		}
+	}
+	if req.Filters != nil && req.Filters.Empty() {
+		req.Filters = nil
+	}
+	if !s.config.Webhook.AllowPrivate {
+		if err := webhook.CheckURL(r.Context(), req.URL); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			res.Error = fmt.Errorf("%w: %v", model.ErrValidation, err).Error()
+			return
+		}
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot create webhook", err)
+		res.Error = err.Error()
+		return
+	}
+
+	hook := &model.Webhook{
+		OwnerID: userID,
+		URL:     req.URL,
+		Events:  req.Events,
+		Filters: req.Filters,
+		Secret:  secret,
+	}
+	if err := s.store.Webhook().Create(hook); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot create webhook", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = hook
+	w.WriteHeader(http.StatusOK)
+	log.Println("created webhook", hook.ID.Hex())
+}
+
+func (s *APIServer) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		res.Error = errNotAuthenticated.Error()
+		return
+	}
+
+	hooks, err := s.store.Webhook().FindByOwner(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find webhooks", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = hooks
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	hook, err := s.store.Webhook().Find(id, userID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find webhook", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = hook
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	if err := s.store.Webhook().Delete(id, userID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot delete webhook", err)
+		res.Error = err.Error()
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.Println("deleted webhook", id.Hex())
+}
+
+// GetWebhookDeliveries returns the delivery log of the webhook, newest first.
+func (s *APIServer) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	if _, err := s.store.Webhook().Find(id, userID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find webhook", err)
+		res.Error = err.Error()
+		return
+	}
+
+	deliveries, err := s.store.WebhookDelivery().FindByWebhook(id, webhookDeliveriesLimit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot find webhook deliveries", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = deliveries
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReplayWebhookDelivery queues the payload of a logged delivery to be sent again.
+func (s *APIServer) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	res := &Response{}
+	defer json.NewEncoder(w).Encode(res)
+
+	userID, id, ok := s.ownedResourceIDs(w, r, res)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := primitive.ObjectIDFromHex(mux.Vars(r)["deliveryID"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		res.Error = "delivery not found"
+		return
+	}
+
+	if _, err := s.store.Webhook().Find(id, userID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find webhook", err)
+		res.Error = err.Error()
+		return
+	}
+
+	delivery, err := s.store.WebhookDelivery().Find(deliveryID, id)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("cannot find webhook delivery", err)
+		res.Error = err.Error()
+		return
+	}
+
+	replay, err := s.webhooks.Replay(delivery)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("cannot replay webhook delivery", err)
+		res.Error = err.Error()
+		return
+	}
+
+	res.Data = replay
+	w.WriteHeader(http.StatusOK)
+	log.Println("replayed webhook delivery", deliveryID.Hex())
+}
@@ -0,0 +1,103 @@
+// Package archive keeps the raw pages vacancies were parsed from, so they
+// can be parsed again after the parser changes.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var ErrNotFound = errors.New("page is not archived")
+
+// Archive stores pages gzipped on disk under the sha256 of their content, so
+// a page which did not change between crawls is stored once.
+type Archive struct {
+	dir string
+}
+
+func New(dir string) *Archive {
+	return &Archive{dir: dir}
+}
+
+func (a *Archive) path(hash string) string {
+	return filepath.Join(a.dir, hash[:2], hash+".gz")
+}
+
+// Put stores the page and returns its hash.
+func (a *Archive) Put(page []byte) (string, error) {
+	sum := sha256.Sum256(page)
+	hash := hex.EncodeToString(sum[:])
+
+	path := a.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("cannot create archive dir: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(page); err != nil {
+		return "", fmt.Errorf("cannot compress page: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("cannot compress page: %w", err)
+	}
+
+	// Readers never see a half written file.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "page-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot archive page: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("cannot archive page: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("cannot archive page: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("cannot archive page: %w", err)
+	}
+
+	return hash, nil
+}
+
+// Get returns the page with the hash.
+func (a *Archive) Get(hash string) ([]byte, error) {
+	if len(hash) < 2 {
+		return nil, ErrNotFound
+	}
+
+	f, err := os.Open(a.path(hash))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("cannot open archived page: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress archived page: %w", err)
+	}
+	defer zr.Close()
+
+	page, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress archived page: %w", err)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,29 @@
+package archive
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchive(t *testing.T) {
+	a := New(t.TempDir())
+	page := []byte(`<html><h1 data-qa="vacancy-title">Go developer</h1></html>`)
+
+	hash, err := a.Put(page)
+	assert.NoError(t, err)
+	again, err := a.Put(page)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, again)
+
+	stored, err := a.Get(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, page, stored)
+
+	files, _ := filepath.Glob(filepath.Join(a.dir, "*", "*"))
+	assert.Len(t, files, 1)
+
+	_, err = a.Get("0000")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
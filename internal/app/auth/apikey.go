@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	apiKeyPrefix       = "vp_"
+	apiKeyVisibleChars = 8
+)
+
+// GenerateAPIKey returns a new random key, its short public prefix used to
+// recognise the key in listings, and the hash to store.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("cannot generate api key: %w", err)
+	}
+
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, key[:len(apiKeyPrefix)+apiKeyVisibleChars], HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes a key for lookup. Keys are random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"time"
+)
+
+type Config struct {
+	Secret     string        `toml:"secret"`
+	AccessTTL  time.Duration `toml:"access_ttl"`
+	RefreshTTL time.Duration `toml:"refresh_ttl"`
+	// AdminEmails are given the admin role by the grant-admin command, once
+	// they have registered. Signing up never grants it.
+	AdminEmails []string `toml:"admin_emails"`
+	// APIKeyQuota is the default number of requests an API key may make per
+	// APIKeyQuotaWindow, 0 means unlimited.
+	APIKeyQuota       int64         `toml:"api_key_quota"`
+	APIKeyQuotaWindow time.Duration `toml:"api_key_quota_window"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+
+		APIKeyQuota:       1000,
+		APIKeyQuotaWindow: time.Hour,
+	}
+}
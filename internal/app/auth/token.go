@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vacancy-parser/internal/app/model"
+)
+
+const (
+	TokenTypeAPIKey  = "api_key"
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token is expired")
+	ErrNoSecret     = errors.New("auth secret is not configured")
+	ErrWeakSecret   = fmt.Errorf("auth secret must be a random string of at least %d bytes", MinSecretLength)
+)
+
+// MinSecretLength is the shortest secret the manager signs tokens with.
+const MinSecretLength = 32
+
+// placeholderSecrets are secrets sample configs and guides tend to ship,
+// which anyone could sign tokens with.
+var placeholderSecrets = []string{"change-me", "changeme", "replace-me", "your-secret"}
+
+// jwtHeader is the only header the manager issues and accepts.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims is the payload of the access and refresh tokens.
+type Claims struct {
+	ID        string   `json:"jti"`
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email"`
+	Roles     []string `json:"roles,omitempty"`
+	Type      string   `json:"typ"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+func (c *Claims) ExpiresTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+// Manager issues and verifies HMAC-SHA256 signed JWTs.
+type Manager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewManager(config *Config) (*Manager, error) {
+	if config.Secret == "" {
+		return nil, ErrNoSecret
+	}
+	if len(config.Secret) < MinSecretLength {
+		return nil, ErrWeakSecret
+	}
+	for _, placeholder := range placeholderSecrets {
+		if strings.Contains(strings.ToLower(config.Secret), placeholder) {
+			return nil, ErrWeakSecret
+		}
+	}
+
+	return &Manager{
+		secret:     []byte(config.Secret),
+		accessTTL:  config.AccessTTL,
+		refreshTTL: config.RefreshTTL,
+		now:        time.Now,
+	}, nil
+}
+
+// Issue creates a new access and refresh token pair for the user.
+func (m *Manager) Issue(user *model.User) (*TokenPair, error) {
+	access, err := m.sign(user, TokenTypeAccess, m.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := m.sign(user, TokenTypeRefresh, m.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(m.accessTTL.Seconds()),
+	}, nil
+}
+
+// Parse verifies the signature, the expiry and the type of the token.
+func (m *Manager) Parse(token, tokenType string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, m.signature(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+	if !m.now().Before(claims.ExpiresTime()) {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+func (m *Manager) sign(user *model.User, tokenType string, ttl time.Duration) (string, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := m.now()
+	payload, err := json.Marshal(&Claims{
+		ID:        id,
+		Subject:   user.ID.Hex(),
+		Email:     user.Email,
+		Roles:     user.Roles,
+		Type:      tokenType,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot encode claims: %w", err)
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(m.signature(unsigned)), nil
+}
+
+func (m *Manager) signature(unsigned string) []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(unsigned))
+
+	return mac.Sum(nil)
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate token id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
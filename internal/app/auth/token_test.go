@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func testManager(t *testing.T) *Manager {
+	t.Helper()
+
+	config := NewConfig()
+	config.Secret = testSecret
+	m, err := NewManager(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestManager_IssueAndParse(t *testing.T) {
+	m := testManager(t)
+	u := model.TestUser(t)
+	u.ID = primitive.NewObjectID()
+	u.Roles = []string{"user"}
+
+	pair, err := m.Issue(u)
+	assert.NoError(t, err)
+
+	claims, err := m.Parse(pair.AccessToken, TokenTypeAccess)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID.Hex(), claims.Subject)
+	assert.Equal(t, u.Email, claims.Email)
+	assert.Equal(t, u.Roles, claims.Roles)
+
+	_, err = m.Parse(pair.RefreshToken, TokenTypeRefresh)
+	assert.NoError(t, err)
+
+	_, err = m.Parse(pair.RefreshToken, TokenTypeAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestManager_ParseInvalid(t *testing.T) {
+	m := testManager(t)
+	pair, err := m.Issue(model.TestUser(t))
+	assert.NoError(t, err)
+
+	other := testManager(t)
+	other.secret = []byte("other")
+	_, err = other.Parse(pair.AccessToken, TokenTypeAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = m.Parse(pair.AccessToken+"x", TokenTypeAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = m.Parse("not.a.token", TokenTypeAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	m.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, err = m.Parse(pair.AccessToken, TokenTypeAccess)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestNewManager_NoSecret(t *testing.T) {
+	_, err := NewManager(NewConfig())
+	assert.ErrorIs(t, err, ErrNoSecret)
+}
+
+func TestNewManager_WeakSecret(t *testing.T) {
+	for _, secret := range []string{"secret", "change-me", "change-me-to-a-long-random-string-please"} {
+		config := NewConfig()
+		config.Secret = secret
+		_, err := NewManager(config)
+		assert.ErrorIs(t, err, ErrWeakSecret, secret)
+	}
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/fetcher"
+	"vacancy-parser/internal/app/model"
+)
+
+type Config struct {
+	// Queries can be referred to by name from crawl jobs and the scheduler.
+	Queries []model.CrawlQuery `toml:"query"`
+	// Workers is the number of vacancy pages fetched concurrently per crawl.
+	// Requests are further limited per host by the fetcher.
+	Workers int `toml:"workers"`
+	// KnownRun is how many known vacancies in a row stop an incremental crawl.
+	KnownRun int `toml:"known_run"`
+	// IncrementalMaxAge is how long after its last fetch a stored vacancy
+	// stays fresh enough for an incremental crawl to skip fetching it again.
+	IncrementalMaxAge time.Duration `toml:"incremental_max_age"`
+	// BlockCooldown is how long a source is paused, in the query mode which
+	// was refused, after it served an anti-bot or login page.
+	BlockCooldown time.Duration `toml:"block_cooldown"`
+	// MaxFailedShare is the share of vacancy fetches which may fail before
+	// a job is marked failed.
+	MaxFailedShare float64 `toml:"max_failed_share"`
+	// ArchiveDir keeps the raw vacancy pages for reparsing. Empty disables
+	// the archive.
+	ArchiveDir string `toml:"archive_dir"`
+	// Selectors is the file of the hh.ru parser selectors, checked for
+	// changes every SelectorsReload. Empty uses the built-in ones.
+	Selectors       string        `toml:"selectors"`
+	SelectorsReload time.Duration `toml:"selectors_reload"`
+	Drift           DriftConfig   `toml:"drift"`
+	// HHAPIURL is the base URL of the hh.ru API used by queries in api mode.
+	HHAPIURL string `toml:"hh_api_url"`
+	// Fetcher loads the pages of every source.
+	Fetcher *fetcher.Config `toml:"fetcher"`
+}
+
+// DriftConfig sets when a job is marked degraded: when a field is filled in
+// less than MaxDrop of its share in the last Baseline jobs. Jobs parsing
+// less than MinSample vacancies are not judged.
+type DriftConfig struct {
+	Baseline  int64   `toml:"baseline"`
+	MinSample int64   `toml:"min_sample"`
+	MaxDrop   float64 `toml:"max_drop"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Workers:           8,
+		KnownRun:          20,
+		IncrementalMaxAge: 72 * time.Hour,
+		BlockCooldown:     10 * time.Minute,
+		MaxFailedShare:    0.5,
+		ArchiveDir:        "archive",
+		SelectorsReload:   30 * time.Second,
+		HHAPIURL:          "https://api.hh.ru",
+		Drift: DriftConfig{
+			Baseline:  10,
+			MinSample: 20,
+			MaxDrop:   0.5,
+		},
+		Fetcher: fetcher.NewConfig(),
+	}
+}
+
+func (c *Config) Validate() error {
+	if c.Workers < 1 {
+		return fmt.Errorf("crawl workers must be positive")
+	}
+	if c.KnownRun < 1 {
+		return fmt.Errorf("crawl known_run must be positive")
+	}
+	if c.MaxFailedShare <= 0 || c.MaxFailedShare > 1 {
+		return fmt.Errorf("crawl max_failed_share must be in (0, 1]")
+	}
+	if c.Drift.MaxDrop <= 0 || c.Drift.MaxDrop > 1 {
+		return fmt.Errorf("crawl drift max_drop must be in (0, 1]")
+	}
+	if c.HHAPIURL == "" {
+		return fmt.Errorf("crawl hh_api_url must be set")
+	}
+	if c.Fetcher.MaxAttempts < 1 {
+		return fmt.Errorf("crawl fetcher max_attempts must be positive")
+	}
+
+	names := make(map[string]bool)
+	for i := range c.Queries {
+		q := &c.Queries[i]
+		if err := q.Validate(); err != nil {
+			return fmt.Errorf("crawl query %q: %w", q.Tag(), err)
+		}
+		if q.Name == "" || names[q.Name] {
+			return fmt.Errorf("crawl query %q: name is empty or duplicated", q.Text)
+		}
+		names[q.Name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,832 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	"vacancy-parser/internal/app/archive"
+	"vacancy-parser/internal/app/events"
+	"vacancy-parser/internal/app/fetcher"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/parser"
+	"vacancy-parser/internal/app/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrJobFinished = errors.New("crawl job has already finished")
+
+const progressInterval = 2 * time.Second
+
+// MatchNotifier is told about new saved search matches found after a crawl.
+type MatchNotifier interface {
+	NotifyMatches(matches []model.SearchMatch)
+}
+
+// Crawler runs crawl jobs in the background, collecting vacancies from its
+// sources into the store. Job records are kept up to date while they run.
+type Crawler struct {
+	config *Config
+	store  *store.Store
+	bus    *events.Bus
+
+	sources   map[string]Source
+	notifiers []MatchNotifier
+	// archive is nil unless enabled.
+	archive *archive.Archive
+
+	mu   sync.Mutex
+	runs map[primitive.ObjectID]*run
+	// blocked sources are paused until the time, separately per query mode
+	// as the site and its API are blocked apart.
+	blocked map[blockKey]time.Time
+}
+
+type blockKey struct {
+	source string
+	mode   string
+}
+
+func newBlockKey(src Source, query model.CrawlQuery) blockKey {
+	return blockKey{source: src.Name(), mode: query.EffectiveMode()}
+}
+
+// run is the live state of a job executed by this process.
+type run struct {
+	mu     sync.Mutex
+	job    model.CrawlJob
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (r *run) update(f func(job *model.CrawlJob)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f(&r.job)
+}
+
+func (r *run) snapshot() model.CrawlJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.job
+	job.Progress = slices.Clone(r.job.Progress)
+	job.Quality.Filled = maps.Clone(r.job.Quality.Filled)
+	job.ModeQuality = slices.Clone(r.job.ModeQuality)
+	for i := range job.ModeQuality {
+		job.ModeQuality[i].Filled = maps.Clone(job.ModeQuality[i].Filled)
+	}
+
+	return job
+}
+
+// New creates a crawler which publishes vacancy and crawl events to bus, which may be nil.
+func New(config *Config, store *store.Store, bus *events.Bus) *Crawler {
+	c := &Crawler{
+		config:  config,
+		store:   store,
+		bus:     bus,
+		sources: make(map[string]Source),
+		runs:    make(map[primitive.ObjectID]*run),
+		blocked: make(map[blockKey]time.Time),
+	}
+	if config.ArchiveDir != "" {
+		c.archive = archive.New(config.ArchiveDir)
+	}
+	c.AddSource(hhSource{fetcher: fetcher.New(config.Fetcher), archive: c.archive, apiURL: config.HHAPIURL})
+
+	return c
+}
+
+// WatchSelectors loads the configured parser selectors and reloads them
+// whenever the file changes, until ctx is done.
+func (c *Crawler) WatchSelectors(ctx context.Context) error {
+	if c.config.Selectors == "" {
+		return nil
+	}
+	if err := parser.LoadSelectors(c.config.Selectors); err != nil {
+		return err
+	}
+	if c.config.SelectorsReload > 0 {
+		go parser.WatchSelectors(ctx, c.config.Selectors, c.config.SelectorsReload)
+	}
+
+	return nil
+}
+
+// AddSource registers a source under its name. It must be called before the first job starts.
+func (c *Crawler) AddSource(src Source) {
+	c.sources[src.Name()] = src
+}
+
+// Sources returns the names of the registered sources.
+func (c *Crawler) Sources() []string {
+	names := make([]string, 0, len(c.sources))
+	for name := range c.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Queries returns the configured queries.
+func (c *Crawler) Queries() []model.CrawlQuery {
+	return c.config.Queries
+}
+
+// Query returns the configured query with the name.
+func (c *Crawler) Query(name string) (model.CrawlQuery, bool) {
+	for _, q := range c.config.Queries {
+		if q.Name == name {
+			return q, true
+		}
+	}
+
+	return model.CrawlQuery{}, false
+}
+
+// AddMatchNotifier must be called before the first job starts.
+func (c *Crawler) AddMatchNotifier(n MatchNotifier) {
+	c.notifiers = append(c.notifiers, n)
+}
+
+// Resume continues the jobs which a previous process left unfinished where
+// they stopped. Jobs which cannot run any more are marked failed. It must be
+// called once, after the match notifiers are added.
+func (c *Crawler) Resume() error {
+	jobs, err := c.store.CrawlJob().FindUnfinished()
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if err := c.validateSources(job); err != nil {
+			now := time.Now().UTC()
+			job.Status = model.JobFailed
+			job.Error = err.Error()
+			job.FinishedAt = &now
+			if err := c.store.CrawlJob().Update(job); err != nil {
+				return err
+			}
+			continue
+		}
+
+		requeued, err := c.store.Frontier().Requeue(job.ID)
+		if err != nil {
+			return err
+		}
+		log.Printf("resuming crawl job %s, requeued links: %d\n", job.ID.Hex(), requeued)
+		c.launch(job)
+	}
+
+	return nil
+}
+
+// Start stores the job and runs it in the background.
+func (c *Crawler) Start(job *model.CrawlJob) error {
+	if err := c.validateSources(job); err != nil {
+		return err
+	}
+
+	// Queries given only by name refer to the configured ones.
+	for i, q := range job.Queries {
+		if q.Text != "" || q.Name == "" {
+			continue
+		}
+		configured, ok := c.Query(q.Name)
+		if !ok {
+			return fmt.Errorf("%w: unknown query %q", model.ErrValidation, q.Name)
+		}
+		job.Queries[i] = configured
+	}
+
+	if err := c.store.CrawlJob().Create(job); err != nil {
+		return err
+	}
+	c.launch(job)
+
+	return nil
+}
+
+func (c *Crawler) validateSources(job *model.CrawlJob) error {
+	for _, name := range job.Sources {
+		if _, ok := c.sources[name]; !ok {
+			return fmt.Errorf("%w: unknown source %q", model.ErrValidation, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *Crawler) launch(job *model.CrawlJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &run{job: *job, cancel: cancel, done: make(chan struct{})}
+
+	c.mu.Lock()
+	c.runs[job.ID] = r
+	c.mu.Unlock()
+
+	go c.execute(ctx, r)
+}
+
+// Run is Start which blocks until the job finishes and returns its final state.
+func (c *Crawler) Run(job *model.CrawlJob) (*model.CrawlJob, error) {
+	if err := c.Start(job); err != nil {
+		return nil, err
+	}
+
+	return c.Wait(job.ID)
+}
+
+// Wait blocks until a job running in this process finishes and returns its
+// final state. Other jobs are returned as stored.
+func (c *Crawler) Wait(id primitive.ObjectID) (*model.CrawlJob, error) {
+	c.mu.Lock()
+	r := c.runs[id]
+	c.mu.Unlock()
+
+	if r != nil {
+		<-r.done
+		finished := r.snapshot()
+		return &finished, nil
+	}
+
+	return c.store.CrawlJob().Find(id)
+}
+
+// Job returns the live state of a job running in this process, or the stored one.
+func (c *Crawler) Job(id primitive.ObjectID) (*model.CrawlJob, error) {
+	c.mu.Lock()
+	r := c.runs[id]
+	c.mu.Unlock()
+
+	if r != nil {
+		job := r.snapshot()
+		return &job, nil
+	}
+
+	return c.store.CrawlJob().Find(id)
+}
+
+// Jobs returns the latest jobs, newest first, with live state for running ones.
+func (c *Crawler) Jobs(limit int64) ([]model.CrawlJob, error) {
+	jobs, err := c.store.CrawlJob().FindRecent(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range jobs {
+		if r := c.runs[jobs[i].ID]; r != nil {
+			jobs[i] = r.snapshot()
+		}
+	}
+
+	return jobs, nil
+}
+
+// Cancel stops a running job. Vacancies written so far are kept.
+func (c *Crawler) Cancel(id primitive.ObjectID) (*model.CrawlJob, error) {
+	c.mu.Lock()
+	r := c.runs[id]
+	c.mu.Unlock()
+
+	if r != nil {
+		r.cancel()
+		job := r.snapshot()
+		return &job, nil
+	}
+
+	job, err := c.store.CrawlJob().Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		return nil, ErrJobFinished
+	}
+
+	// Not running here, so nothing will ever finish it.
+	now := time.Now().UTC()
+	job.Status = model.JobCanceled
+	job.FinishedAt = &now
+	if err := c.store.CrawlJob().Update(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (c *Crawler) execute(ctx context.Context, r *run) {
+	defer func() {
+		r.cancel()
+		c.mu.Lock()
+		delete(c.runs, r.job.ID)
+		c.mu.Unlock()
+		close(r.done)
+	}()
+
+	r.update(func(job *model.CrawlJob) {
+		job.Status = model.JobRunning
+		job.Selectors = parser.CurrentSelectors().Version
+		if job.StartedAt == nil {
+			startedAt := time.Now().UTC()
+			job.StartedAt = &startedAt
+		}
+	})
+	c.save(r)
+
+	stopProgress := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopProgress:
+				return
+			case <-ticker.C:
+				c.save(r)
+			}
+		}
+	}()
+
+	job := r.snapshot()
+	var created []*model.Vacancy
+	var errs []error
+	for _, name := range job.Sources {
+		for _, query := range job.Queries {
+			if ctx.Err() != nil {
+				break
+			}
+			if job.QueryProgress(name, query).Done {
+				continue
+			}
+			vacancies, err := c.crawl(ctx, r, c.sources[name], query)
+			if err != nil {
+				log.Println("crawl failed", name, query.Tag(), err)
+				errs = append(errs, fmt.Errorf("%s %q: %w", name, query.Tag(), err))
+			}
+			if err == nil && ctx.Err() == nil {
+				r.update(func(job *model.CrawlJob) {
+					job.QueryProgress(name, query).Done = true
+				})
+			}
+			created = append(created, vacancies...)
+		}
+	}
+	close(stopProgress)
+
+	matches, err := c.matchSavedSearches(created)
+	if err != nil {
+		log.Println("cannot match saved searches", err)
+	} else {
+		log.Println("new saved search matches:", len(matches))
+		for _, n := range c.notifiers {
+			n.NotifyMatches(matches)
+		}
+	}
+
+	var drifts []model.FieldDrift
+	if ctx.Err() == nil {
+		drifts = c.detectDrift(r.snapshot())
+	}
+
+	finishedAt := time.Now().UTC()
+	r.update(func(job *model.CrawlJob) {
+		job.Degraded = drifts
+		job.FinishedAt = &finishedAt
+		job.BlockedUntil = nil
+		switch {
+		case ctx.Err() != nil:
+			job.Status = model.JobCanceled
+		case job.Counters.Blocked > 0 && job.Counters.Parsed == 0:
+			job.Status = model.JobFailed
+			errs = append(errs, errors.New("blocked by the site"))
+		case len(errs) > 0 && job.Counters.Parsed == 0:
+			job.Status = model.JobFailed
+		case failedShare(job) > c.config.MaxFailedShare:
+			job.Status = model.JobFailed
+			errs = append(errs, fmt.Errorf("%d of %d vacancy fetches failed", job.FetchFailures(), job.Counters.Fetched+job.FetchFailures()))
+		default:
+			job.Status = model.JobSucceeded
+		}
+		if len(errs) > 0 {
+			job.Error = errors.Join(errs...).Error()
+		}
+	})
+	c.save(r)
+
+	finished := r.snapshot()
+	if err := c.store.Frontier().DeleteByJob(finished.ID); err != nil {
+		log.Println(err)
+	}
+	c.bus.Publish(events.Event{Type: events.CrawlFinished, Job: &finished})
+	if len(finished.Degraded) > 0 {
+		c.bus.Publish(events.Event{Type: events.CrawlDegraded, Job: &finished})
+	}
+	log.Printf("crawl job %s %s: %+v\n", finished.ID.Hex(), finished.Status, finished.Counters)
+}
+
+// crawl runs one query against one source and returns the vacancies it created.
+// Every listed vacancy is marked seen. Stored vacancies of the query which a
+// full crawl did not see are closed, but only when the listing ran out before
+// the page limit and every listed vacancy was fetched. Vacancies which are
+// gone from the site are closed as they are fetched.
+// An incremental crawl lists the newest vacancies first, does not fetch
+// fresh known ones again and stops after a run of them.
+//
+// Listed links go to the job's frontier before they are fetched, so a
+// resumed job first fetches what it had left and then lists on from the
+// page it stopped at.
+func (c *Crawler) crawl(ctx context.Context, r *run, src Source, query model.CrawlQuery) ([]*model.Vacancy, error) {
+	repo := c.store.Vacancy()
+	frontier := c.store.Frontier()
+	job := r.snapshot()
+	startedAt := *job.StartedAt
+	progress := *job.QueryProgress(src.Name(), query)
+
+	// Parsed vacancies are finished in the frontier only once they are
+	// stored, so a job interrupted before a flush fetches them again.
+	var unwrittenMu sync.Mutex
+	unwritten := make(map[*model.Vacancy]primitive.ObjectID)
+	finishWritten := func(v *model.Vacancy, writeErr error) {
+		unwrittenMu.Lock()
+		id, ok := unwritten[v]
+		delete(unwritten, v)
+		unwrittenMu.Unlock()
+		if !ok {
+			return
+		}
+		if err := frontier.Finish(id, writeErr); err != nil {
+			log.Println(err)
+		}
+	}
+
+	writer := repo.NewBatchWriter(func(e store.BulkItemError) {
+		log.Println(e)
+		finishWritten(e.Vacancy, e.Err)
+		r.update(func(job *model.CrawlJob) {
+			job.Counters.Failed++
+		})
+	})
+	writer.OnFlush(func(res *store.BulkResult) {
+		for _, v := range res.Written {
+			finishWritten(v, nil)
+		}
+		c.bus.PublishWritten(res.Created, res.Changed)
+		r.update(func(job *model.CrawlJob) {
+			job.Counters.Inserted += res.Inserted
+			job.Counters.Updated += res.Updated
+		})
+	})
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.config.Workers)
+	fetch := func(item model.FrontierItem) {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				<-sem
+			}()
+
+			link := item.Link
+			if err := frontier.Start(item.ID); err != nil {
+				log.Println(err)
+			}
+			vacancy, err := c.fetch(ctx, r, src, link, query)
+			if vacancy != nil && err == nil {
+				unwrittenMu.Lock()
+				unwritten[vacancy] = item.ID
+				unwrittenMu.Unlock()
+			} else {
+				// Vacancies which are gone or disallowed will not come back on a retry.
+				finishErr := err
+				if errors.Is(err, fetcher.ErrNotFound) || errors.Is(err, fetcher.ErrDisallowed) {
+					finishErr = nil
+				}
+				if err := frontier.Finish(item.ID, finishErr); err != nil {
+					log.Println(err)
+				}
+			}
+			switch {
+			case errors.Is(err, fetcher.ErrNotFound):
+				log.Println("vacancy is gone", link)
+				r.update(func(job *model.CrawlJob) {
+					job.Counters.Skipped++
+				})
+				c.close(r, link)
+			case errors.Is(err, fetcher.ErrDisallowed):
+				// The listing marked the vacancy seen, so it is not closed
+				// as unseen, and it will not be fetched on a later crawl either.
+				r.update(func(job *model.CrawlJob) {
+					job.Counters.Skipped++
+				})
+			case errors.Is(err, fetcher.ErrParse):
+				log.Println("cannot parse vacancy", link, err)
+				c.keepUnparsed(link, query, err)
+				r.update(func(job *model.CrawlJob) {
+					job.Counters.Failed++
+					job.QueryProgress(src.Name(), query).FetchFailures++
+					job.Quality.AddFailed()
+					job.QualityOf(query.EffectiveMode()).AddFailed()
+				})
+			case err != nil:
+				log.Println("cannot fetch vacancy", link, err)
+				r.update(func(job *model.CrawlJob) {
+					job.Counters.Failed++
+					job.QueryProgress(src.Name(), query).FetchFailures++
+				})
+			case vacancy == nil:
+				log.Println("skipped page without a vacancy", link)
+				r.update(func(job *model.CrawlJob) {
+					job.Counters.Fetched++
+					job.Counters.Skipped++
+				})
+			default:
+				r.update(func(job *model.CrawlJob) {
+					job.Counters.Fetched++
+					job.Counters.Parsed++
+					job.Quality.Add(vacancy)
+					job.QualityOf(query.EffectiveMode()).Add(vacancy)
+				})
+				writer.Input() <- vacancy
+			}
+		}()
+	}
+
+	pending, err := frontier.FindPending(job.ID, src.Name(), query.Tag())
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		fetch(item)
+	}
+
+	knownRun := 0
+	nextPage := progress.NextPage
+	stopped := false
+	page := func(links []string) bool {
+		defer func() {
+			nextPage++
+			r.update(func(job *model.CrawlJob) {
+				job.QueryProgress(src.Name(), query).NextPage = nextPage
+			})
+		}()
+
+		var fresh map[string]bool
+		if job.Incremental {
+			var err error
+			fresh, err = repo.FindFresh(links, startedAt.Add(-c.config.IncrementalMaxAge))
+			if err != nil {
+				log.Println("cannot find known vacancies", err)
+			}
+		}
+
+		var known, unknown []string
+		for _, link := range links {
+			if fresh[link] {
+				known = append(known, link)
+				continue
+			}
+			unknown = append(unknown, link)
+		}
+		if err := repo.MarkSeen(links); err != nil {
+			log.Println(err)
+		}
+
+		// Listings shift while they are walked, so the frontier drops links
+		// which showed up on an earlier page.
+		items, err := frontier.Add(job.ID, src.Name(), query.Tag(), unknown)
+		if err != nil {
+			log.Println(err)
+			stopped = true
+			return false
+		}
+		added := make(map[string]model.FrontierItem, len(items))
+		for _, item := range items {
+			added[item.Link] = item
+		}
+		r.update(func(job *model.CrawlJob) {
+			job.Counters.Listed += int64(len(items) + len(known))
+			job.Counters.Known += int64(len(known))
+		})
+
+		for _, link := range links {
+			if ctx.Err() != nil {
+				stopped = true
+				return false
+			}
+			if !fresh[link] {
+				knownRun = 0
+				if item, ok := added[link]; ok {
+					fetch(item)
+				}
+				continue
+			}
+			if knownRun++; knownRun >= c.config.KnownRun {
+				stopped = true
+				return false
+			}
+		}
+
+		return true
+	}
+	// A blocked listing is walked on from the page it stopped at after the cooldown.
+	key := newBlockKey(src, query)
+	for attempt := 1; !progress.Listed && c.waitUnblocked(ctx, r, key); attempt++ {
+		opts := ListOptions{Pages: job.Pages, StartPage: nextPage, NewestFirst: job.Incremental}
+		err = src.List(ctx, query, opts, page)
+		if !errors.Is(err, fetcher.ErrBlocked) {
+			break
+		}
+		c.block(r, key, err)
+		if attempt >= model.MaxFetchAttempts {
+			break
+		}
+	}
+	wg.Wait()
+	if err == nil && ctx.Err() == nil && !progress.Listed {
+		r.update(func(job *model.CrawlJob) {
+			p := job.QueryProgress(src.Name(), query)
+			p.Listed = true
+			p.Exhausted = !stopped && nextPage < job.Pages
+		})
+	}
+
+	writer.Close()
+	if err != nil {
+		return writer.Created(), err
+	}
+
+	// A canceled or incremental crawl has not seen everything, nor has one
+	// which hit the page limit or failed to fetch some vacancies. One which
+	// listed nothing most likely failed to reach the site: none may close vacancies.
+	job = r.snapshot()
+	progress = *job.QueryProgress(src.Name(), query)
+	if ctx.Err() == nil && !job.Incremental && progress.Exhausted && progress.FetchFailures == 0 && nextPage > 0 {
+		closed, err := repo.CloseUnseen(query, startedAt)
+		if err != nil {
+			log.Println("cannot close unseen vacancies", err)
+		}
+		r.update(func(job *model.CrawlJob) {
+			job.Counters.Closed += int64(len(closed))
+		})
+		for i := range closed {
+			c.bus.Publish(events.Event{Type: events.VacancyClosed, Vacancy: &closed[i]})
+		}
+	}
+
+	return writer.Created(), nil
+}
+
+// failedShare is the share of the vacancies the job tried to fetch which it
+// could not.
+func failedShare(job *model.CrawlJob) float64 {
+	failures := job.FetchFailures()
+	if failures == 0 {
+		return 0
+	}
+
+	return float64(failures) / float64(job.Counters.Fetched+failures)
+}
+
+// keepUnparsed records an archived page which failed to parse, for a reparse.
+func (c *Crawler) keepUnparsed(link string, query model.CrawlQuery, err error) {
+	var unparsed *UnparsedError
+	if !errors.As(err, &unparsed) {
+		return
+	}
+
+	page := &model.UnparsedPage{Link: link, Tag: query.Tag(), Language: query.Text, Raw: *unparsed.Raw, Error: unparsed.Err.Error()}
+	if err := c.store.UnparsedPage().Save(page); err != nil {
+		log.Println(err)
+	}
+}
+
+// close closes a vacancy which is gone from the site.
+func (c *Crawler) close(r *run, link string) {
+	closed, err := c.store.Vacancy().Close(link)
+	if err != nil {
+		if !errors.Is(err, store.ErrRecordNotFound) {
+			log.Println(err)
+		}
+		return
+	}
+
+	r.update(func(job *model.CrawlJob) {
+		job.Counters.Closed++
+	})
+	c.bus.Publish(events.Event{Type: events.VacancyClosed, Vacancy: closed})
+}
+
+// detectDrift compares the fill rates of the job's fields with the latest
+// jobs in the same query mode and logs the fields which collapsed, most
+// likely as their selectors stopped matching.
+func (c *Crawler) detectDrift(job model.CrawlJob) []model.FieldDrift {
+	var drifts []model.FieldDrift
+	for _, quality := range job.ModeQuality {
+		baseline, err := c.store.CrawlJob().FindBaseline(quality.Mode, c.config.Drift.Baseline)
+		if err != nil {
+			log.Println("cannot check parse quality", err)
+			continue
+		}
+		drifts = append(drifts, model.DetectDrift(quality, baseline, c.config.Drift.MinSample, c.config.Drift.MaxDrop)...)
+	}
+
+	for _, d := range drifts {
+		log.Printf("crawl job %s is degraded: %s filled in %.0f%% of %s vacancies, usually %.0f%% (selectors %q)\n",
+			job.ID.Hex(), d.Field, d.Rate*100, d.Mode, d.Baseline*100, job.Selectors)
+	}
+
+	return drifts
+}
+
+// fetch loads a vacancy, waiting out the cooldown whenever the source is blocked.
+func (c *Crawler) fetch(ctx context.Context, r *run, src Source, link string, query model.CrawlQuery) (*model.Vacancy, error) {
+	key := newBlockKey(src, query)
+	err := context.Canceled
+	for attempt := 1; c.waitUnblocked(ctx, r, key); attempt++ {
+		var vacancy *model.Vacancy
+		vacancy, err = src.Fetch(ctx, link, query)
+		if !errors.Is(err, fetcher.ErrBlocked) {
+			return vacancy, err
+		}
+		c.block(r, key, err)
+		if attempt >= model.MaxFetchAttempts {
+			break
+		}
+	}
+
+	return nil, err
+}
+
+// block pauses the source in the query mode for the cooldown after it was
+// refused or served an anti-bot page.
+func (c *Crawler) block(r *run, key blockKey, err error) {
+	r.update(func(job *model.CrawlJob) {
+		job.Counters.Blocked++
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	until := time.Now().Add(c.config.BlockCooldown)
+	if c.blocked[key].Before(time.Now()) {
+		log.Printf("source %s is blocked in %s mode until %s: %v\n", key.source, key.mode, until.Format(time.RFC3339), err)
+		c.blocked[key] = until
+	}
+}
+
+// waitUnblocked waits until the cooldown of the source in the query mode is
+// over, showing the job as blocked meanwhile. It returns false when ctx is
+// done first.
+func (c *Crawler) waitUnblocked(ctx context.Context, r *run, key blockKey) bool {
+	for {
+		c.mu.Lock()
+		until := c.blocked[key]
+		c.mu.Unlock()
+
+		if !time.Now().Before(until) {
+			r.update(func(job *model.CrawlJob) {
+				if job.Status == model.JobBlocked {
+					job.Status = model.JobRunning
+					job.BlockedUntil = nil
+				}
+			})
+			return ctx.Err() == nil
+		}
+
+		r.update(func(job *model.CrawlJob) {
+			job.Status = model.JobBlocked
+			job.BlockedUntil = &until
+		})
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Crawler) save(r *run) {
+	job := r.snapshot()
+	if err := c.store.CrawlJob().Update(&job); err != nil {
+		log.Println("cannot save crawl job", err)
+	}
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrawler_BlockByMode(t *testing.T) {
+	config := NewConfig()
+	config.ArchiveDir = ""
+	config.BlockCooldown = time.Hour
+	c := New(config, nil, nil)
+	src := c.sources["hh"]
+	r := &run{}
+
+	html := newBlockKey(src, model.CrawlQuery{Text: "Go"})
+	api := newBlockKey(src, model.CrawlQuery{Text: "Go", Mode: model.QueryModeAPI})
+	assert.Equal(t, html, newBlockKey(src, model.CrawlQuery{Text: "Java", Mode: model.QueryModeHTML}))
+	c.block(r, html, errors.New("captcha"))
+	assert.Equal(t, int64(1), r.job.Counters.Blocked)
+
+	// A captcha on the site does not pause queries going through the API.
+	assert.True(t, c.waitUnblocked(context.Background(), r, api))
+	assert.Equal(t, "", r.job.Status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, c.waitUnblocked(ctx, r, html))
+	assert.Equal(t, model.JobBlocked, r.job.Status)
+}
+
+func TestFailedShare(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fetched  int64
+		failures []int64
+		share    float64
+	}{
+		{"nothing fetched", 0, nil, 0},
+		{"no failures", 10, []int64{0, 0}, 0},
+		{"some failures", 6, []int64{1, 1}, 0.25},
+		{"all failed", 0, []int64{3}, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := &model.CrawlJob{Counters: model.CrawlCounters{Fetched: tc.fetched}}
+			for _, f := range tc.failures {
+				job.Progress = append(job.Progress, model.CrawlProgress{FetchFailures: f})
+			}
+			assert.Equal(t, tc.share, failedShare(job))
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package crawler_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/archive"
+	"vacancy-parser/internal/app/crawler"
+	"vacancy-parser/internal/app/fetcher"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var databaseURL string
+
+func TestMain(m *testing.M) {
+	databaseURL = os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		databaseURL = "mongodb://localhost:27017"
+	}
+	os.Exit(m.Run())
+}
+
+var collections = []string{"vacancies", "crawl_jobs", "crawl_frontier", "saved_searches", "inbox", "unparsed_pages"}
+
+// fakeSource lists its pages and serves a vacancy for every link, unless
+// the link has an error.
+type fakeSource struct {
+	pages [][]string
+	errs  map[string]error
+	// hold, when set, keeps fetches waiting until it is closed.
+	hold chan struct{}
+
+	mu      sync.Mutex
+	listed  []int
+	fetched []string
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) List(ctx context.Context, query model.CrawlQuery, opts crawler.ListOptions, page func(links []string) bool) error {
+	for i := opts.StartPage; i < opts.Pages && i < len(s.pages); i++ {
+		s.mu.Lock()
+		s.listed = append(s.listed, i)
+		s.mu.Unlock()
+		if !page(s.pages[i]) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeSource) Fetch(ctx context.Context, link string, query model.CrawlQuery) (*model.Vacancy, error) {
+	if s.hold != nil {
+		select {
+		case <-s.hold:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	s.fetched = append(s.fetched, link)
+	s.mu.Unlock()
+
+	if err := s.errs[link]; err != nil {
+		return nil, err
+	}
+
+	return &model.Vacancy{Title: "Go developer", Link: link, Company: "Acme", Site: "hh.ru", Tags: []string{query.Tag()}}, nil
+}
+
+// Parse takes the archived page for the vacancy title.
+func (s *fakeSource) Parse(link string, page []byte, stored model.Vacancy) (*model.Vacancy, error) {
+	return &model.Vacancy{Title: string(page), Link: link, Site: "hh.ru", MainLanguage: stored.MainLanguage}, nil
+}
+
+func (s *fakeSource) Fetched() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.fetched...)
+}
+
+func newCrawler(t *testing.T, st *store.Store, src crawler.Source) *crawler.Crawler {
+	t.Helper()
+
+	config := crawler.NewConfig()
+	config.ArchiveDir = ""
+	config.Selectors = ""
+	config.Workers = 2
+	config.BlockCooldown = 10 * time.Millisecond
+	c := crawler.New(config, st, nil)
+	c.AddSource(src)
+
+	return c
+}
+
+func newJob() *model.CrawlJob {
+	return &model.CrawlJob{
+		Sources: []string{"fake"},
+		Queries: []model.CrawlQuery{{Name: "go", Text: "Go"}},
+		Pages:   5,
+	}
+}
+
+func TestCrawler_Run(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	src := &fakeSource{pages: [][]string{
+		{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2"},
+		{"https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"},
+	}}
+	c := newCrawler(t, st, src)
+
+	job, err := c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, model.JobSucceeded, job.Status)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, model.CrawlCounters{Listed: 3, Fetched: 3, Parsed: 3, Inserted: 3}, job.Counters)
+	assert.ElementsMatch(t, []string{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"}, src.Fetched())
+
+	stored, err := st.CrawlJob().Find(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, job.Counters, stored.Counters)
+	assert.Equal(t, model.JobSucceeded, stored.Status)
+
+	vacancy, err := st.Vacancy().FindVacancyByLink("https://hh.ru/vacancy/3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go"}, vacancy.Tags)
+
+	// The second run finds the same vacancies, unchanged.
+	job, err = c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), job.Counters.Inserted)
+	assert.Equal(t, int64(0), job.Counters.Updated)
+}
+
+func TestCrawler_MatchSavedSearches(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	userID := primitive.NewObjectID()
+	search := &model.SavedSearch{UserID: userID, Name: "go", Filters: model.Filters{Text: "go developer"}}
+	require.NoError(t, st.SavedSearch().Create(search))
+	require.NoError(t, st.SavedSearch().Create(&model.SavedSearch{UserID: userID, Name: "java", Filters: model.Filters{Text: "java"}}))
+
+	src := &fakeSource{pages: [][]string{{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2"}}}
+	c := newCrawler(t, st, src)
+	_, err := c.Run(newJob())
+	require.NoError(t, err)
+
+	// The second run finds a new vacancy next to the delivered ones.
+	src.pages = [][]string{{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"}}
+	_, err = c.Run(newJob())
+	require.NoError(t, err)
+
+	matches, err := st.Inbox().FindByUser(userID, false)
+	require.NoError(t, err)
+	var got []string
+	for _, m := range matches {
+		assert.Equal(t, search.ID, m.SearchID)
+		got = append(got, m.Vacancy.Link)
+	}
+	assert.ElementsMatch(t, []string{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"}, got)
+}
+
+func TestCrawler_StartUnknownSource(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	c := newCrawler(t, st, &fakeSource{})
+	job := newJob()
+	job.Sources = []string{"habr"}
+
+	assert.ErrorIs(t, c.Start(job), model.ErrValidation)
+}
+
+func TestCrawler_Cancel(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	src := &fakeSource{pages: [][]string{{"https://hh.ru/vacancy/1"}}, hold: make(chan struct{})}
+	c := newCrawler(t, st, src)
+
+	job := newJob()
+	require.NoError(t, c.Start(job))
+
+	_, err := c.Cancel(job.ID)
+	require.NoError(t, err)
+	finished, err := c.Wait(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobCanceled, finished.Status)
+
+	stored, err := st.CrawlJob().Find(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobCanceled, stored.Status)
+
+	_, err = c.Cancel(job.ID)
+	assert.ErrorIs(t, err, crawler.ErrJobFinished)
+}
+
+func TestCrawler_CloseVacancies(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	one, two, three := "https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"
+	src := &fakeSource{pages: [][]string{{one, two, three}}}
+	c := newCrawler(t, st, src)
+	_, err := c.Run(newJob())
+	require.NoError(t, err)
+
+	closed := func(link string) bool {
+		v, err := st.Vacancy().FindVacancyByLink(link)
+		require.NoError(t, err)
+		return v.Closed
+	}
+
+	// A failed fetch leaves the crawl incomplete, so nothing unseen is closed.
+	src.pages = [][]string{{one}}
+	src.errs = map[string]error{one: &fetcher.Error{URL: one, Kind: fetcher.ErrTransient}}
+	job, err := c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), job.Counters.Closed)
+	assert.False(t, closed(two))
+
+	// Neither does a crawl which hit the page limit.
+	src.errs = nil
+	job = newJob()
+	job.Pages = 1
+	job, err = c.Run(job)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), job.Counters.Closed)
+	assert.False(t, closed(two))
+
+	// A gone vacancy is closed right away, and a complete crawl closes the unseen ones.
+	src.pages = [][]string{{one, three}}
+	src.errs = map[string]error{three: &fetcher.Error{URL: three, StatusCode: 404, Kind: fetcher.ErrNotFound}}
+	job, err = c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), job.Counters.Closed)
+	assert.False(t, closed(one))
+	assert.True(t, closed(two))
+	assert.True(t, closed(three))
+}
+
+func TestCrawler_Resume(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	one, two, three, four := "https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3", "https://hh.ru/vacancy/4"
+
+	// A job interrupted after listing the first page: one link was in
+	// flight, one was still pending and one was done.
+	job := newJob()
+	require.NoError(t, st.CrawlJob().Create(job))
+	startedAt := time.Now().UTC()
+	job.Status = model.JobRunning
+	job.StartedAt = &startedAt
+	job.QueryProgress("fake", job.Queries[0]).NextPage = 1
+	require.NoError(t, st.CrawlJob().Update(job))
+
+	items, err := st.Frontier().Add(job.ID, "fake", "go", []string{one, two, three})
+	require.NoError(t, err)
+	require.NoError(t, st.Frontier().Start(items[0].ID))
+	require.NoError(t, st.Frontier().Start(items[2].ID))
+	require.NoError(t, st.Frontier().Finish(items[2].ID, nil))
+
+	src := &fakeSource{pages: [][]string{{one, two, three}, {four}}}
+	c := newCrawler(t, st, src)
+	require.NoError(t, c.Resume())
+
+	finished, err := c.Wait(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobSucceeded, finished.Status)
+	assert.Equal(t, []int{1}, src.listed)
+	assert.ElementsMatch(t, []string{one, two, four}, src.Fetched())
+	assert.Equal(t, int64(3), finished.Counters.Inserted)
+
+	pending, err := st.Frontier().FindPending(job.ID, "fake", "go")
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestCrawler_DisallowedIsSeen(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	one, two, three := "https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"
+	src := &fakeSource{pages: [][]string{{one, two, three}}}
+	c := newCrawler(t, st, src)
+	_, err := c.Run(newJob())
+	require.NoError(t, err)
+
+	src.pages = [][]string{{one, two}}
+	src.errs = map[string]error{two: &fetcher.Error{URL: two, Kind: fetcher.ErrDisallowed}}
+	job, err := c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), job.Counters.Skipped)
+	assert.Equal(t, int64(1), job.Counters.Closed)
+
+	v, err := st.Vacancy().FindVacancyByLink(two)
+	require.NoError(t, err)
+	assert.False(t, v.Closed)
+	v, err = st.Vacancy().FindVacancyByLink(three)
+	require.NoError(t, err)
+	assert.True(t, v.Closed)
+}
+
+func TestCrawler_FailedShare(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	one, two, three := "https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"
+	src := &fakeSource{
+		pages: [][]string{{one, two, three}},
+		errs: map[string]error{
+			one: &fetcher.Error{URL: one, Kind: fetcher.ErrTransient},
+			two: &fetcher.Error{URL: two, Kind: fetcher.ErrTransient},
+		},
+	}
+	c := newCrawler(t, st, src)
+
+	job, err := c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), job.Counters.Parsed)
+	assert.Equal(t, model.JobFailed, job.Status)
+	assert.Contains(t, job.Error, "2 of 3 vacancy fetches failed")
+}
+
+func TestCrawler_ReparseUnparsed(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	config := crawler.NewConfig()
+	config.ArchiveDir = t.TempDir()
+	config.Selectors = ""
+	hash, err := archive.New(config.ArchiveDir).Put([]byte("Go developer"))
+	require.NoError(t, err)
+
+	one := "https://hh.ru/vacancy/1"
+	raw := &model.RawPage{Hash: hash, Source: "fake", Format: model.RawFormatHTML}
+	src := &fakeSource{
+		pages: [][]string{{one}},
+		errs:  map[string]error{one: fetcher.ParseError(one, &crawler.UnparsedError{Raw: raw, Err: errors.New("vacancy title not found")})},
+	}
+	c := crawler.New(config, st, nil)
+	c.AddSource(src)
+
+	job, err := c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), job.Counters.Parsed)
+	_, err = st.Vacancy().FindVacancyByLink(one)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	res, err := c.Reparse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Unparsed)
+	assert.Equal(t, int64(1), res.Recovered)
+
+	v, err := st.Vacancy().FindVacancyByLink(one)
+	require.NoError(t, err)
+	assert.Equal(t, "Go developer", v.Title)
+	assert.Equal(t, "Go", v.MainLanguage)
+	assert.Equal(t, []string{"go"}, v.Tags)
+
+	// Recovered pages are not tried again.
+	res, err = c.Reparse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.Unparsed)
+}
+
+func TestCrawler_DriftFromParseFailures(t *testing.T) {
+	st, teardown := store.TestStore(t, databaseURL)
+	defer teardown(collections...)
+
+	config := crawler.NewConfig()
+	config.ArchiveDir = ""
+	config.Selectors = ""
+	config.Drift.MinSample = 2
+	src := &fakeSource{pages: [][]string{{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2", "https://hh.ru/vacancy/3"}}}
+	c := crawler.New(config, st, nil)
+	c.AddSource(src)
+
+	job, err := c.Run(newJob())
+	require.NoError(t, err)
+	require.Empty(t, job.Degraded)
+
+	// A title selector which stopped matching fails every page.
+	src.errs = make(map[string]error)
+	for _, link := range src.pages[0] {
+		src.errs[link] = fetcher.ParseError(link, errors.New("vacancy title not found"))
+	}
+	job, err = c.Run(newJob())
+	require.NoError(t, err)
+	assert.Equal(t, model.JobFailed, job.Status)
+	assert.Equal(t, int64(3), job.QualityOf(model.QueryModeHTML).Parsed)
+	require.NotEmpty(t, job.Degraded)
+	assert.Equal(t, model.FieldDrift{Mode: model.QueryModeHTML, Field: model.FieldTitle, Rate: 0, Baseline: 1}, job.Degraded[0])
+
+	// API queries are not judged against the site.
+	src.errs = nil
+	job = newJob()
+	job.Queries[0].Mode = model.QueryModeAPI
+	job, err = c.Run(job)
+	require.NoError(t, err)
+	assert.Empty(t, job.Degraded)
+}
@@ -0,0 +1,35 @@
+package crawler
+
+import (
+	"vacancy-parser/internal/app/model"
+)
+
+// matchSavedSearches evaluates every saved search against newly ingested
+// vacancies and adds the matches to the owners' inboxes. It returns the
+// matches which were not in the inboxes before.
+func (c *Crawler) matchSavedSearches(vacancies []*model.Vacancy) ([]model.SearchMatch, error) {
+	if len(vacancies) == 0 {
+		return nil, nil
+	}
+
+	searches, err := c.store.SavedSearch().FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []model.SearchMatch
+	for _, search := range searches {
+		for _, v := range vacancies {
+			if search.Filters.Match(v) {
+				matches = append(matches, model.SearchMatch{
+					UserID:     search.UserID,
+					SearchID:   search.ID,
+					SearchName: search.Name,
+					Vacancy:    *v,
+				})
+			}
+		}
+	}
+
+	return c.store.Inbox().AddMatches(matches)
+}
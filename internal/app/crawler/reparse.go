@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"vacancy-parser/internal/app/events"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+)
+
+const reparseBatchSize = 100
+
+// ReparseResult counts the vacancies a reparse went through.
+type ReparseResult struct {
+	Archived int64 `json:"archived"`
+	Parsed   int64 `json:"parsed"`
+	Failed   int64 `json:"failed"`
+	Updated  int64 `json:"updated"`
+	// Unparsed pages tried again, and the vacancies recovered from them.
+	Unparsed  int64 `json:"unparsed"`
+	Recovered int64 `json:"recovered"`
+}
+
+// Reparse parses the archived pages of the stored vacancies again with the
+// current parsers and saves the vacancies whose content changed. Archived
+// pages which failed to parse when they were fetched are tried too, and the
+// vacancies recovered from them are stored. It does not touch the network.
+func (c *Crawler) Reparse(ctx context.Context) (*ReparseResult, error) {
+	if c.archive == nil {
+		return nil, errors.New("page archive is disabled")
+	}
+
+	res := &ReparseResult{}
+	var batch []*model.Vacancy
+	flush := func() error {
+		changed, err := c.store.Vacancy().UpdateContent(batch)
+		if err != nil {
+			return err
+		}
+		res.Updated += int64(len(changed))
+		for _, v := range changed {
+			c.bus.Publish(events.Event{Type: events.VacancyUpdated, Vacancy: v})
+		}
+		batch = batch[:0]
+
+		return nil
+	}
+
+	err := c.store.Vacancy().EachArchived(func(stored model.Vacancy) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res.Archived++
+
+		src, ok := c.sources[stored.Raw.Source].(Reparser)
+		if !ok {
+			log.Println("cannot reparse vacancy", stored.Link, "of source", stored.Raw.Source)
+			res.Failed++
+			return nil
+		}
+		page, err := c.archive.Get(stored.Raw.Hash)
+		if err != nil {
+			log.Println("cannot reparse vacancy", stored.Link, err)
+			res.Failed++
+			return nil
+		}
+		v, err := src.Parse(stored.Link, page, stored)
+		if err != nil {
+			log.Println("cannot reparse vacancy", stored.Link, err)
+			res.Failed++
+			return nil
+		}
+		res.Parsed++
+
+		// Only the content comes from the page.
+		v.Tags = stored.Tags
+		v.Closed = stored.Closed
+		v.ClosedAt = stored.ClosedAt
+		v.FirstSeenAt = stored.FirstSeenAt
+		v.LastSeenAt = stored.LastSeenAt
+		v.LastFetchedAt = stored.LastFetchedAt
+		v.Raw = stored.Raw
+
+		batch = append(batch, v)
+		if len(batch) >= reparseBatchSize {
+			return flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	if err := flush(); err != nil {
+		return res, err
+	}
+
+	return res, c.reparseUnparsed(ctx, res)
+}
+
+// reparseUnparsed tries the pages the crawler could not parse again.
+func (c *Crawler) reparseUnparsed(ctx context.Context, res *ReparseResult) error {
+	var recovered []*model.Vacancy
+	flush := func() error {
+		written, err := c.store.Vacancy().UpsertVacancies(recovered)
+		if err != nil {
+			return err
+		}
+		c.bus.PublishWritten(written.Created, written.Changed)
+		res.Recovered += int64(len(written.Written))
+		for _, v := range written.Written {
+			if err := c.store.UnparsedPage().Delete(v.Link); err != nil {
+				return err
+			}
+		}
+		recovered = recovered[:0]
+
+		return nil
+	}
+
+	err := c.store.UnparsedPage().Each(func(unparsed model.UnparsedPage) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res.Unparsed++
+
+		// The vacancy may have been fetched and parsed since.
+		if _, err := c.store.Vacancy().FindVacancyByLink(unparsed.Link); err == nil {
+			return c.store.UnparsedPage().Delete(unparsed.Link)
+		} else if !errors.Is(err, store.ErrRecordNotFound) {
+			return err
+		}
+
+		src, ok := c.sources[unparsed.Raw.Source].(Reparser)
+		if !ok {
+			log.Println("cannot reparse vacancy", unparsed.Link, "of source", unparsed.Raw.Source)
+			res.Failed++
+			return nil
+		}
+		page, err := c.archive.Get(unparsed.Raw.Hash)
+		if err != nil {
+			log.Println("cannot reparse vacancy", unparsed.Link, err)
+			res.Failed++
+			return nil
+		}
+		raw := unparsed.Raw
+		v, err := src.Parse(unparsed.Link, page, model.Vacancy{Link: unparsed.Link, MainLanguage: unparsed.Language, Raw: &raw})
+		if err != nil {
+			log.Println("cannot reparse vacancy", unparsed.Link, err)
+			res.Failed++
+			return nil
+		}
+		res.Parsed++
+
+		v.Tags = []string{unparsed.Tag}
+		v.Raw = &raw
+		recovered = append(recovered, v)
+		if len(recovered) >= reparseBatchSize {
+			return flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
@@ -0,0 +1,190 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"vacancy-parser/internal/app/archive"
+	"vacancy-parser/internal/app/fetcher"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/parser"
+)
+
+// Source is a job site the crawler can collect vacancies from.
+type Source interface {
+	Name() string
+	// List walks the listing pages of the query from opts.StartPage up to
+	// opts.Pages and passes the vacancy links of each to page. It stops early
+	// when page returns false.
+	List(ctx context.Context, query model.CrawlQuery, opts ListOptions, page func(links []string) bool) error
+	// Fetch loads and parses one vacancy. A nil vacancy without an error
+	// means the page held no vacancy and was skipped. Errors should be
+	// *fetcher.Error values, so the crawler can tell their kind.
+	Fetch(ctx context.Context, link string, query model.CrawlQuery) (*model.Vacancy, error)
+}
+
+// UnparsedError is the cause of an ErrParse error for a page which was
+// archived before it failed to parse, so a reparse can try it again.
+type UnparsedError struct {
+	Raw *model.RawPage
+	Err error
+}
+
+func (e *UnparsedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UnparsedError) Unwrap() error {
+	return e.Err
+}
+
+// Reparser is a source which can parse its archived pages again.
+type Reparser interface {
+	// Parse parses a vacancy page loaded from the link of a stored vacancy.
+	Parse(link string, page []byte, stored model.Vacancy) (*model.Vacancy, error)
+}
+
+type ListOptions struct {
+	Pages     int
+	StartPage int
+	// NewestFirst orders the listing by publication date.
+	NewestFirst bool
+}
+
+// hhSource scrapes the hh.ru website, or uses its JSON API at apiURL for
+// queries in api mode. Both modes store vacancies under the site links.
+type hhSource struct {
+	fetcher *fetcher.Fetcher
+	// archive is nil unless enabled.
+	archive *archive.Archive
+	apiURL  string
+}
+
+func (hhSource) Name() string {
+	return "hh"
+}
+
+// get loads a page, treating anti-bot and login pages as ErrBlocked. These
+// slow down further requests to hh.ru like a 429 does. Fresh pages are
+// revalidated even when cached recently.
+func (s hhSource) get(ctx context.Context, url string, fresh bool) (*fetcher.Response, error) {
+	get := s.fetcher.Get
+	if fresh {
+		get = s.fetcher.GetFresh
+	}
+	res, err := get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if reason := parser.DetectBlock(res.URL, res.Body); reason != "" {
+		s.fetcher.SlowDown(url)
+		s.fetcher.Forget(url)
+		return nil, &fetcher.Error{URL: url, Kind: fetcher.ErrBlocked, Err: errors.New(reason)}
+	}
+
+	return res, nil
+}
+
+func (s hhSource) List(ctx context.Context, query model.CrawlQuery, opts ListOptions, page func(links []string) bool) error {
+	if query.Mode == model.QueryModeAPI {
+		return s.listAPI(ctx, query, opts, page)
+	}
+
+	for i := opts.StartPage; i < opts.Pages && ctx.Err() == nil; i++ {
+		url := parser.SearchURL(query, i, opts.NewestFirst)
+		res, err := s.get(ctx, url, true)
+		if err != nil {
+			return fmt.Errorf("cannot list page %d: %w", i, err)
+		}
+		links, err := parser.ParseListing(bytes.NewReader(res.Body))
+		if err != nil {
+			return fmt.Errorf("cannot list page %d: %w", i, fetcher.ParseError(url, err))
+		}
+		if len(links) == 0 || !page(links) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// listAPI is List for queries in api mode. The API answers with JSON, so
+// blocks come as 403 statuses rather than captcha pages.
+func (s hhSource) listAPI(ctx context.Context, query model.CrawlQuery, opts ListOptions, page func(links []string) bool) error {
+	for i := opts.StartPage; i < opts.Pages && ctx.Err() == nil; i++ {
+		url := parser.APISearchURL(s.apiURL, query, i, opts.NewestFirst)
+		res, err := s.fetcher.GetFresh(ctx, url)
+		if err != nil {
+			return fmt.Errorf("cannot list page %d: %w", i, err)
+		}
+		links, more, err := parser.ParseAPIListing(res.Body)
+		if err != nil {
+			return fmt.Errorf("cannot list page %d: %w", i, fetcher.ParseError(url, err))
+		}
+		if len(links) == 0 || !page(links) || !more {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s hhSource) Fetch(ctx context.Context, link string, query model.CrawlQuery) (*model.Vacancy, error) {
+	var (
+		res    *fetcher.Response
+		format = model.RawFormatHTML
+		err    error
+	)
+	if query.Mode == model.QueryModeAPI {
+		format = model.RawFormatJSON
+		res, err = s.fetcher.Get(ctx, parser.APIVacancyURL(s.apiURL, link))
+	} else {
+		res, err = s.get(ctx, link, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages are archived before they are parsed, so the ones the parser
+	// fails on can be parsed again once it is fixed.
+	var raw *model.RawPage
+	if s.archive != nil {
+		hash, err := s.archive.Put(res.Body)
+		if err != nil {
+			log.Println(err)
+		} else {
+			raw = &model.RawPage{Hash: hash, Source: s.Name(), Format: format, FetchedAt: res.FetchedAt}
+		}
+	}
+
+	vacancy, err := s.parse(format, link, res.Body, query.Text)
+	if err != nil {
+		if raw != nil {
+			err = &UnparsedError{Raw: raw, Err: err}
+		}
+		return nil, fetcher.ParseError(link, err)
+	}
+	vacancy.Tags = []string{query.Tag()}
+	vacancy.Raw = raw
+
+	return vacancy, nil
+}
+
+func (s hhSource) Parse(link string, page []byte, stored model.Vacancy) (*model.Vacancy, error) {
+	format := model.RawFormatHTML
+	if stored.Raw != nil && stored.Raw.Format != "" {
+		format = stored.Raw.Format
+	}
+
+	return s.parse(format, link, page, stored.MainLanguage)
+}
+
+func (hhSource) parse(format, link string, page []byte, language string) (*model.Vacancy, error) {
+	if format == model.RawFormatJSON {
+		return parser.ParseAPIVacancy(page, link, language)
+	}
+
+	return parser.ParseVacancy(bytes.NewReader(page), link, language)
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vacancy-parser/internal/app/archive"
+	"vacancy-parser/internal/app/fetcher"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSource(t *testing.T, handler http.Handler) (hhSource, *httptest.Server) {
+	t.Helper()
+
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	config := fetcher.NewConfig()
+	config.Robots = false
+	config.MaxAttempts = 1
+	config.Limit.Jitter = 0
+	config.Limit.RequestsPerSecond = 100
+
+	return hhSource{fetcher: fetcher.New(config), archive: archive.New(t.TempDir()), apiURL: ts.URL}, ts
+}
+
+func TestHHSource_FetchArchivesUnparsedPage(t *testing.T) {
+	page := `<html><body><p>Nothing to see</p></body></html>`
+	src, ts := testSource(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(page))
+	}))
+
+	_, err := src.Fetch(context.Background(), ts.URL+"/vacancy/1", model.CrawlQuery{Text: "Go"})
+	assert.ErrorIs(t, err, fetcher.ErrParse)
+	assert.ErrorIs(t, err, parser.ErrNoTitle)
+
+	var unparsed *UnparsedError
+	require.True(t, errors.As(err, &unparsed))
+	assert.Equal(t, "hh", unparsed.Raw.Source)
+	assert.Equal(t, model.RawFormatHTML, unparsed.Raw.Format)
+	archived, err := src.archive.Get(unparsed.Raw.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, page, string(archived))
+}
+
+func TestHHSource_API(t *testing.T) {
+	src, _ := testSource(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/vacancies":
+			page := r.URL.Query().Get("page")
+			assert.Equal(t, "Go", r.URL.Query().Get("text"))
+			assert.Equal(t, "113", r.URL.Query().Get("area"))
+			if page == "0" {
+				w.Write([]byte(`{"items": [{"id": "1", "alternate_url": "https://hh.ru/vacancy/1"}], "page": 0, "pages": 2}`))
+			} else {
+				w.Write([]byte(`{"items": [{"id": "2", "alternate_url": "https://spb.hh.ru/vacancy/2?from=api"}], "page": 1, "pages": 2}`))
+			}
+		case "/vacancies/1":
+			w.Write([]byte(`{
+				"id": "1",
+				"name": "Go developer",
+				"area": {"name": "Москва"},
+				"employer": {"name": "Acme"},
+				"salary": {"from": 300000, "currency": "RUR", "gross": false},
+				"experience": {"id": "between1And3", "name": "От 1 года до 3 лет"},
+				"key_skills": [{"name": "Go"}],
+				"published_at": "2024-05-01T10:00:00+0300"
+			}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	query := model.CrawlQuery{Text: "Go", Areas: []string{"113"}, Mode: model.QueryModeAPI}
+
+	var links []string
+	err := src.List(context.Background(), query, ListOptions{Pages: 5}, func(page []string) bool {
+		links = append(links, page...)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2"}, links)
+
+	vacancy, err := src.Fetch(context.Background(), links[0], query)
+	require.NoError(t, err)
+	assert.Equal(t, "Go developer", vacancy.Title)
+	assert.Equal(t, "https://hh.ru/vacancy/1", vacancy.Link)
+	assert.Equal(t, "Москва", vacancy.Location)
+	assert.Equal(t, "Acme", vacancy.Company)
+	assert.Equal(t, "от 300 000 ₽ на руки", vacancy.Salary)
+	assert.Equal(t, "1–3 года", vacancy.Experience)
+	assert.Equal(t, "2024-05-01", vacancy.Date)
+	assert.Equal(t, []string{query.Tag()}, vacancy.Tags)
+	require.NotNil(t, vacancy.Raw)
+	assert.Equal(t, model.RawFormatJSON, vacancy.Raw.Format)
+
+	_, err = src.Fetch(context.Background(), links[1], query)
+	assert.ErrorIs(t, err, fetcher.ErrNotFound)
+}
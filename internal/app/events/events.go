@@ -0,0 +1,156 @@
+package events
+
+import (
+	"sync"
+	"time"
+	"vacancy-parser/internal/app/model"
+)
+
+const (
+	VacancyCreated = "vacancy.created"
+	VacancyUpdated = "vacancy.updated"
+	VacancyClosed  = "vacancy.closed"
+	CrawlFinished  = "crawl.finished"
+	// CrawlDegraded follows CrawlFinished when fields stopped being parsed.
+	CrawlDegraded = "crawl.degraded"
+)
+
+// Types lists every event type, in the order they are documented.
+var Types = []string{VacancyCreated, VacancyUpdated, VacancyClosed, CrawlFinished, CrawlDegraded}
+
+func ValidType(t string) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Event struct {
+	// ID grows by one with every published event. The first ID is taken from
+	// the clock, so IDs keep growing across restarts.
+	ID      uint64          `json:"id"`
+	Type    string          `json:"type"`
+	Time    time.Time       `json:"time"`
+	Vacancy *model.Vacancy  `json:"vacancy,omitempty"`
+	Job     *model.CrawlJob `json:"job,omitempty"`
+}
+
+// DefaultHistory is the number of recent events a bus keeps for replay.
+const DefaultHistory = 1024
+
+// Bus fans published events out to subscribers and keeps the most recent
+// ones for subscribers resuming after a disconnect. Publishing never blocks:
+// a subscriber whose buffer is full misses the event.
+type Bus struct {
+	mu      sync.Mutex
+	lastID  uint64
+	subs    map[chan Event]struct{}
+	history []Event
+	next    int
+	full    bool
+}
+
+func NewBus() *Bus {
+	return NewBusWithHistory(DefaultHistory)
+}
+
+func NewBusWithHistory(size int) *Bus {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &Bus{
+		lastID:  uint64(time.Now().UnixMicro()),
+		subs:    make(map[chan Event]struct{}),
+		history: make([]Event, size),
+	}
+}
+
+// Publish assigns the event its ID and time and delivers it. A nil Bus drops everything.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e.ID = b.lastID
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	b.history[b.next] = e
+	b.next = (b.next + 1) % len(b.history)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// PublishWritten publishes vacancy.created and vacancy.updated for a written batch.
+func (b *Bus) PublishWritten(created, changed []*model.Vacancy) {
+	for _, v := range created {
+		b.Publish(Event{Type: VacancyCreated, Vacancy: v})
+	}
+	for _, v := range changed {
+		b.Publish(Event{Type: VacancyUpdated, Vacancy: v})
+	}
+}
+
+// Subscribe returns a channel of events published from now on and a function
+// which unsubscribes and closes the channel.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	_, ch, cancel := b.SubscribeSince(0, buffer)
+	return ch, cancel
+}
+
+// SubscribeSince is Subscribe which also returns the kept events published
+// after the lastID event, oldest first. With lastID 0 nothing is replayed.
+// No event is lost or repeated between the returned slice and the channel.
+func (b *Bus) SubscribeSince(lastID uint64, buffer int) ([]Event, <-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	var missed []Event
+	if lastID > 0 {
+		missed = b.since(lastID)
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return missed, ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			close(ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+func (b *Bus) since(lastID uint64) []Event {
+	kept := b.history[:b.next]
+	if b.full {
+		kept = append(append([]Event(nil), b.history[b.next:]...), b.history[:b.next]...)
+	}
+
+	for i, e := range kept {
+		if e.ID > lastID {
+			return append([]Event(nil), kept[i:]...)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// cachedHeaders are the response headers kept in the cache.
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified"}
+
+// pruneEvery is how many saved pages trigger a prune.
+const pruneEvery = 1000
+
+// Cache keeps fetched pages on disk, one JSON file per URL. Pages not saved
+// for maxAge are pruned, and the oldest ones while the cache holds more than
+// maxSize bytes. Zero limits are not enforced.
+type Cache struct {
+	dir     string
+	maxAge  time.Duration
+	maxSize int64
+
+	saves   atomic.Int64
+	pruning atomic.Bool
+}
+
+func NewCache(dir string, maxAge time.Duration, maxSize int64) *Cache {
+	return &Cache{dir: dir, maxAge: maxAge, maxSize: maxSize}
+}
+
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	key := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// Load returns the cached page of url, or nil if there is none.
+func (c *Cache) Load(url string) (*Response, error) {
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read cached page: %w", err)
+	}
+
+	var res Response
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("cannot decode cached page: %w", err)
+	}
+	res.FromCache = true
+
+	return &res, nil
+}
+
+// Save stores the page under the URL it was requested by.
+func (c *Cache) Save(url string, res *Response) error {
+	header := http.Header{}
+	for _, name := range cachedHeaders {
+		if value := res.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	stored := *res
+	stored.Header = header
+
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("cannot encode page: %w", err)
+	}
+
+	path := c.path(url)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create cache dir: %w", err)
+	}
+	// Readers never see a half written file.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "page-*")
+	if err != nil {
+		return fmt.Errorf("cannot cache page: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot cache page: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot cache page: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cannot cache page: %w", err)
+	}
+
+	if c.saves.Add(1)%pruneEvery == 0 {
+		go c.prune()
+	}
+
+	return nil
+}
+
+func (c *Cache) prune() {
+	if !c.pruning.CompareAndSwap(false, true) {
+		return
+	}
+	defer c.pruning.Store(false)
+
+	if removed, err := c.Prune(); err != nil {
+		log.Println(err)
+	} else if removed > 0 {
+		log.Println("pruned cached pages:", removed)
+	}
+}
+
+// Prune removes the pages which are too old, then the oldest ones until the
+// cache fits its size, and returns how many it removed.
+func (c *Cache) Prune() (int, error) {
+	if c.maxAge <= 0 && c.maxSize <= 0 {
+		return 0, nil
+	}
+
+	type page struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var pages []page
+	var size int64
+	removed := 0
+	expired := time.Now().Add(-c.maxAge)
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if c.maxAge > 0 && info.ModTime().Before(expired) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			removed++
+			return nil
+		}
+		pages = append(pages, page{path: path, size: info.Size(), modTime: info.ModTime()})
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("cannot prune cache: %w", err)
+	}
+
+	if c.maxSize <= 0 || size <= c.maxSize {
+		return removed, nil
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].modTime.Before(pages[j].modTime)
+	})
+	for _, p := range pages {
+		if size <= c.maxSize {
+			break
+		}
+		if err := os.Remove(p.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("cannot prune cache: %w", err)
+		}
+		size -= p.size
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Delete drops the cached page of url.
+func (c *Cache) Delete(url string) error {
+	if err := os.Remove(c.path(url)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot delete cached page: %w", err)
+	}
+
+	return nil
+}
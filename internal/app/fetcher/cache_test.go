@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetcher_GetCached(t *testing.T) {
+	requests, notModified := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte("vacancy"))
+	}))
+	defer ts.Close()
+
+	config := testConfig()
+	config.Cache = true
+	config.CacheDir = t.TempDir()
+	config.CacheTTL = time.Hour
+	f := New(config)
+
+	res, err := f.Get(context.Background(), ts.URL+"/vacancy/1")
+	assert.NoError(t, err)
+	assert.False(t, res.FromCache)
+
+	// A fresh page is served without a request.
+	res, err = f.Get(context.Background(), ts.URL+"/vacancy/1")
+	assert.NoError(t, err)
+	assert.True(t, res.FromCache)
+	assert.Equal(t, "vacancy", string(res.Body))
+	assert.Empty(t, res.Header.Get("Set-Cookie"))
+	assert.Equal(t, 1, requests)
+
+	// A stale one is revalidated.
+	config.CacheTTL = 0
+	res, err = f.Get(context.Background(), ts.URL+"/vacancy/1")
+	assert.NoError(t, err)
+	assert.True(t, res.FromCache)
+	assert.Equal(t, "vacancy", string(res.Body))
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModified)
+
+	// Offline fetchers use only the cache.
+	config.Offline = true
+	res, err = f.Get(context.Background(), ts.URL+"/vacancy/1")
+	assert.NoError(t, err)
+	assert.Equal(t, "vacancy", string(res.Body))
+	_, err = f.Get(context.Background(), ts.URL+"/vacancy/2")
+	assert.ErrorIs(t, err, ErrNotCached)
+	assert.Equal(t, 2, requests)
+}
+
+func TestCache_Prune(t *testing.T) {
+	page := &Response{URL: "https://hh.ru/vacancy/1", StatusCode: http.StatusOK, Body: make([]byte, 1000)}
+	save := func(c *Cache, url string, age time.Duration) {
+		assert.NoError(t, c.Save(url, page))
+		modTime := time.Now().Add(-age)
+		assert.NoError(t, os.Chtimes(c.path(url), modTime, modTime))
+	}
+
+	// Pages not saved within the max age go first.
+	c := NewCache(t.TempDir(), 24*time.Hour, 0)
+	save(c, "old", 48*time.Hour)
+	save(c, "new", time.Hour)
+	removed, err := c.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, c.path("old"))
+	assert.FileExists(t, c.path("new"))
+
+	// Then the oldest ones until the cache fits.
+	c = NewCache(t.TempDir(), 0, 2500)
+	save(c, "1", 3*time.Hour)
+	save(c, "2", 2*time.Hour)
+	save(c, "3", time.Hour)
+	removed, err = c.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.NoFileExists(t, c.path("2"))
+	assert.FileExists(t, c.path("3"))
+
+	// A cache without limits keeps everything.
+	c = NewCache(t.TempDir(), 0, 0)
+	save(c, "1", 1000*time.Hour)
+	removed, err = c.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
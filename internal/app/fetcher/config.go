@@ -0,0 +1,67 @@
+package fetcher
+
+import "time"
+
+type Config struct {
+	// Timeout of one request, retries not included.
+	Timeout time.Duration `toml:"timeout"`
+	// MaxAttempts per request, the first one included.
+	MaxAttempts int `toml:"max_attempts"`
+	// The n-th retry waits a random time between half and all of
+	// InitialBackoff * 2^(n-1), at most MaxBackoff. A longer Retry-After
+	// sent by the site is waited instead, also at most MaxBackoff.
+	InitialBackoff time.Duration `toml:"initial_backoff"`
+	MaxBackoff     time.Duration `toml:"max_backoff"`
+	UserAgent      string        `toml:"user_agent"`
+	// Robots makes every request honour the robots.txt rules of its host
+	// for RobotsAgent, including Crawl-delay. The files are cached for RobotsTTL.
+	Robots      bool          `toml:"robots"`
+	RobotsAgent string        `toml:"robots_agent"`
+	RobotsTTL   time.Duration `toml:"robots_ttl"`
+	// Cache keeps fetched pages in CacheDir. Pages younger than CacheTTL are
+	// served from it, older ones are revalidated with conditional requests.
+	// Offline fetchers serve every page from the cache and request nothing.
+	Cache    bool          `toml:"cache"`
+	CacheDir string        `toml:"cache_dir"`
+	CacheTTL time.Duration `toml:"cache_ttl"`
+	// Pages not fetched for CacheMaxAge are pruned, then the oldest ones
+	// while the cache is larger than CacheMaxSizeMB. Offline fetchers prune
+	// nothing, zero disables either limit.
+	CacheMaxAge    time.Duration `toml:"cache_max_age"`
+	CacheMaxSizeMB int64         `toml:"cache_max_size_mb"`
+	Offline        bool          `toml:"offline"`
+	// Limit applies to every host without an entry in Hosts.
+	Limit HostLimit            `toml:"limit"`
+	Hosts map[string]HostLimit `toml:"hosts"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Timeout:        15 * time.Second,
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		UserAgent:      "Mozilla/5.0 (compatible; vacancy-parser/1.0)",
+		Robots:         true,
+		RobotsAgent:    "vacancy-parser",
+		RobotsTTL:      24 * time.Hour,
+		CacheDir:       "cache/http",
+		CacheTTL:       6 * time.Hour,
+		CacheMaxAge:    7 * 24 * time.Hour,
+		CacheMaxSizeMB: 2048,
+		Limit: HostLimit{
+			RequestsPerSecond: 2,
+			Burst:             4,
+			MaxConcurrency:    4,
+			Jitter:            300 * time.Millisecond,
+		},
+	}
+}
+
+func (c *Config) hostLimit(host string) HostLimit {
+	if limit, ok := c.Hosts[host]; ok {
+		return limit
+	}
+
+	return c.Limit
+}
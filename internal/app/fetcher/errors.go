@@ -0,0 +1,77 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of fetch errors, to be checked with errors.Is.
+var (
+	// ErrTransient failures may succeed when tried again later.
+	ErrTransient = errors.New("transient error")
+	// ErrNotFound pages are gone, like closed vacancies.
+	ErrNotFound = errors.New("not found")
+	// ErrBlocked requests were refused by the site.
+	ErrBlocked = errors.New("blocked")
+	// ErrDisallowed pages may not be fetched by the robots.txt rules.
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+	// ErrNotCached pages are missing from the cache of an offline fetcher.
+	ErrNotCached = errors.New("not cached")
+	// ErrParse pages were loaded but did not have the expected content.
+	ErrParse = errors.New("cannot parse page")
+)
+
+// Error is a failed fetch of a URL. Kind is one of the kinds above, or nil
+// for responses which fit none of them.
+type Error struct {
+	URL        string
+	StatusCode int
+	Kind       error
+	Err        error
+}
+
+func (e *Error) Error() string {
+	msg := "cannot fetch " + e.URL
+	if e.Kind != nil {
+		msg += ": " + e.Kind.Error()
+	}
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(": status %d", e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+
+	return errs
+}
+
+// ParseError reports that the page at url did not have the expected content.
+func ParseError(url string, err error) error {
+	return &Error{URL: url, Kind: ErrParse, Err: err}
+}
+
+func statusError(url string, status int) *Error {
+	e := &Error{URL: url, StatusCode: status}
+	switch {
+	case status == 404 || status == 410:
+		e.Kind = ErrNotFound
+	case status == 403 || status == 401:
+		e.Kind = ErrBlocked
+	case status == 429 || status == 408 || status >= 500:
+		e.Kind = ErrTransient
+	}
+
+	return e
+}
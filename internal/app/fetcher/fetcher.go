@@ -0,0 +1,294 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxBodySize limits the pages read. Vacancy pages are a few hundred KB,
+// larger bodies are refused rather than parsed truncated.
+const maxBodySize = 8 << 20
+
+// Response is a successfully fetched page.
+type Response struct {
+	// URL the page was loaded from, after redirects.
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// FetchedAt is when the page was last loaded or revalidated.
+	FetchedAt time.Time
+	// FromCache pages were served from the cache, revalidated or not.
+	FromCache bool `json:"-"`
+}
+
+// Fetcher loads pages for the crawl sources. Requests which fail with a
+// transient error are retried with backoff. Requests to each host are
+// rate limited, and slowed down further while the host pushes back.
+type Fetcher struct {
+	config *Config
+	client *http.Client
+	// cache is nil unless enabled.
+	cache *Cache
+
+	mu          sync.Mutex
+	hosts       map[string]*hostLimiter
+	robotsCache map[string]*robotsEntry
+}
+
+func New(config *Config) *Fetcher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 16
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = config.Timeout
+
+	f := &Fetcher{
+		config:      config,
+		client:      &http.Client{Timeout: config.Timeout, Transport: transport},
+		hosts:       make(map[string]*hostLimiter),
+		robotsCache: make(map[string]*robotsEntry),
+	}
+	switch {
+	case config.Offline:
+		f.cache = NewCache(config.CacheDir, 0, 0)
+	case config.Cache:
+		f.cache = NewCache(config.CacheDir, config.CacheMaxAge, config.CacheMaxSizeMB<<20)
+		go f.cache.prune()
+	}
+
+	return f
+}
+
+func (f *Fetcher) limiter(rawURL string) *hostLimiter {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Hostname()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	l, ok := f.hosts[host]
+	if !ok {
+		l = newHostLimiter(host, f.config.hostLimit(host))
+		f.hosts[host] = l
+	}
+
+	return l
+}
+
+// SlowDown lowers the request rate to the host of url, for sources which
+// recognise pushback the fetcher cannot, like captcha pages.
+func (f *Fetcher) SlowDown(url string) {
+	f.limiter(url).slowDown()
+}
+
+// Forget drops the cached page of url, for sources which find out that the
+// page is not what it should be, like a captcha.
+func (f *Fetcher) Forget(url string) {
+	if f.cache == nil {
+		return
+	}
+	if err := f.cache.Delete(url); err != nil {
+		log.Println(err)
+	}
+}
+
+// Get loads the page at url. Errors are *Error values, except for the
+// context ones when ctx is done.
+func (f *Fetcher) Get(ctx context.Context, url string) (*Response, error) {
+	return f.load(ctx, url, f.config.CacheTTL)
+}
+
+// GetFresh is Get for pages which change often, like listings: a cached
+// page is always revalidated, unless the fetcher is offline.
+func (f *Fetcher) GetFresh(ctx context.Context, url string) (*Response, error) {
+	return f.load(ctx, url, 0)
+}
+
+func (f *Fetcher) load(ctx context.Context, url string, ttl time.Duration) (*Response, error) {
+	var cached *Response
+	if f.cache != nil {
+		var err error
+		if cached, err = f.cache.Load(url); err != nil {
+			log.Println(err)
+		}
+		switch {
+		case f.config.Offline && cached == nil:
+			return nil, &Error{URL: url, Kind: ErrNotCached}
+		case cached != nil && (f.config.Offline || time.Since(cached.FetchedAt) < ttl):
+			return cached, nil
+		}
+	}
+
+	if f.config.Robots {
+		if err := f.checkRobots(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := f.fetch(ctx, url, cached)
+	if err != nil {
+		return nil, err
+	}
+	if f.cache != nil {
+		if err := f.cache.Save(url, res); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return res, nil
+}
+
+// fetch requests the page, retrying transient errors. A cached page is
+// revalidated and returned when it has not changed.
+func (f *Fetcher) fetch(ctx context.Context, url string, cached *Response) (*Response, error) {
+	limiter := f.limiter(url)
+	for attempt := 1; ; attempt++ {
+		release, err := limiter.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res, retryAfter, err := f.get(ctx, url, cached)
+		release()
+
+		var fetchErr *Error
+		switch {
+		case err == nil:
+			limiter.speedUp()
+			return res, nil
+		case errors.As(err, &fetchErr) && (fetchErr.StatusCode == http.StatusTooManyRequests || fetchErr.StatusCode == http.StatusServiceUnavailable):
+			limiter.slowDown()
+		}
+		if !errors.Is(err, ErrTransient) || attempt >= f.config.MaxAttempts {
+			return nil, err
+		}
+
+		delay := max(f.backoff(attempt), retryAfter)
+		if delay > f.config.MaxBackoff {
+			delay = f.config.MaxBackoff
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *Fetcher) get(ctx context.Context, url string, cached *Response) (*Response, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, &Error{URL: url, Err: err}
+	}
+	if f.config.UserAgent != "" {
+		req.Header.Set("User-Agent", f.config.UserAgent)
+	}
+	if cached != nil {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, &Error{URL: url, Kind: networkKind(err), Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		revalidated := *cached
+		revalidated.FetchedAt = time.Now().UTC()
+		return &revalidated, 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+		return nil, RetryAfter(resp.Header.Get("Retry-After"), time.Now()), statusError(url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, &Error{URL: url, Kind: ErrTransient, Err: fmt.Errorf("cannot read body: %w", err)}
+	}
+	if len(body) > maxBodySize {
+		return nil, 0, ParseError(url, fmt.Errorf("body is larger than %d bytes", maxBodySize))
+	}
+
+	return &Response{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		FetchedAt:  time.Now().UTC(),
+	}, 0, nil
+}
+
+// networkKind tells timeouts and dropped connections, which are worth
+// retrying, from errors like unknown hosts, refused connections, malformed
+// URLs or bad certificates, which come back the same on every attempt.
+func networkKind(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTransient
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTransient
+	}
+
+	return nil
+}
+
+// backoff returns the jittered delay before retry attempt+1.
+func (f *Fetcher) backoff(attempt int) time.Duration {
+	delay := f.config.InitialBackoff
+	for i := 1; i < attempt && delay < f.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > f.config.MaxBackoff {
+		delay = f.config.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// RetryAfter parses a Retry-After header given in seconds or as a date.
+// It returns 0 when the header is missing or invalid.
+func RetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
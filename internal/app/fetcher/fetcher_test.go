@@ -0,0 +1,125 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() *Config {
+	return &Config{
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}
+}
+
+func TestFetcher_Get(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/flaky":
+			if calls < 3 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte("ok"))
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/blocked":
+			w.WriteHeader(http.StatusForbidden)
+		case "/huge":
+			w.Write(bytes.Repeat([]byte("a"), maxBodySize+1))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	f := New(testConfig())
+
+	res, err := f.Get(context.Background(), ts.URL+"/flaky")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(res.Body))
+	assert.Equal(t, 3, calls)
+
+	for _, tc := range []struct {
+		path  string
+		kind  error
+		calls int
+	}{
+		{"/down", ErrTransient, 3},
+		{"/blocked", ErrBlocked, 1},
+		{"/gone", ErrNotFound, 1},
+		{"/huge", ErrParse, 1},
+	} {
+		calls = 0
+		_, err := f.Get(context.Background(), ts.URL+tc.path)
+		assert.ErrorIs(t, err, tc.kind, tc.path)
+		assert.Equal(t, tc.calls, calls, tc.path)
+	}
+}
+
+func TestFetcher_GetCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	config := testConfig()
+	config.MaxBackoff = time.Hour
+	config.InitialBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := New(config).Get(ctx, ts.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, RetryAfter("30", now))
+	assert.Equal(t, 2*time.Minute, RetryAfter("Wed, 01 May 2024 12:02:00 GMT", now))
+	assert.Equal(t, time.Duration(0), RetryAfter("Wed, 01 May 2024 11:00:00 GMT", now))
+	assert.Equal(t, time.Duration(0), RetryAfter("soon", now))
+	assert.Equal(t, time.Duration(0), RetryAfter("", now))
+}
+
+func TestNetworkKind(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, ErrTransient},
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, ErrTransient},
+		{"eof", &url.Error{Op: "Get", Err: io.EOF}, ErrTransient},
+		{"unknown host", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, nil},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, nil},
+	} {
+		assert.Equal(t, tc.want, networkKind(tc.err), tc.name)
+	}
+
+	// A host nobody listens on is not retried.
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+	config := testConfig()
+	config.InitialBackoff = time.Hour
+	_, err := New(config).Get(context.Background(), ts.URL)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTransient)
+}
@@ -0,0 +1,154 @@
+package fetcher
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// minRateShare is how far slow-downs may lower the rate of a host, as a
+// share of its configured rate.
+const minRateShare = 1.0 / 16
+
+// HostLimit is the politeness towards one host.
+type HostLimit struct {
+	// RequestsPerSecond and Burst configure a token bucket.
+	RequestsPerSecond float64 `toml:"requests_per_second"`
+	Burst             int     `toml:"burst"`
+	// MaxConcurrency limits the requests in flight.
+	MaxConcurrency int `toml:"max_concurrency"`
+	// Jitter is the maximum random delay added before each request.
+	Jitter time.Duration `toml:"jitter"`
+}
+
+// hostLimiter paces the requests to a host. Its rate is halved whenever
+// the host pushes back and recovers slowly on successful responses.
+type hostLimiter struct {
+	host  string
+	limit HostLimit
+	slots chan struct{}
+
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newHostLimiter(host string, limit HostLimit) *hostLimiter {
+	return &hostLimiter{
+		host:   host,
+		limit:  limit,
+		slots:  make(chan struct{}, max(limit.MaxConcurrency, 1)),
+		rate:   limit.RequestsPerSecond,
+		tokens: float64(max(limit.Burst, 1)),
+		last:   time.Now(),
+	}
+}
+
+// acquire waits for a free slot and a token. The returned func frees the slot.
+func (l *hostLimiter) acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-l.slots }
+
+	if err := sleep(ctx, l.reserve(time.Now())); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (l *hostLimiter) reserve(now time.Time) time.Duration {
+	var jitter time.Duration
+	if l.limit.Jitter > 0 {
+		jitter = rand.N(l.limit.Jitter)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return jitter
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if burst := float64(max(l.limit.Burst, 1)); l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return jitter
+	}
+
+	return time.Duration(-l.tokens/l.rate*float64(time.Second)) + jitter
+}
+
+// setCrawlDelay lowers the configured rate to one request per delay.
+func (l *hostLimiter) setCrawlDelay(delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := float64(time.Second) / float64(delay)
+	if l.limit.RequestsPerSecond > 0 && l.limit.RequestsPerSecond <= rate {
+		return
+	}
+	l.limit.RequestsPerSecond = rate
+	l.limit.Burst = 1
+	l.rate = min(l.rate, rate)
+	if l.rate == 0 {
+		l.rate = rate
+	}
+	l.tokens = min(l.tokens, 1)
+	log.Printf("robots.txt crawl delay for %s: %s\n", l.host, delay)
+}
+
+func (l *hostLimiter) slowDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rate := max(l.rate/2, l.limit.RequestsPerSecond*minRateShare); rate < l.rate {
+		l.rate = rate
+		log.Printf("slowing down requests to %s: %.2f/s\n", l.host, l.rate)
+	}
+}
+
+func (l *hostLimiter) speedUp() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = min(l.rate*1.05, l.limit.RequestsPerSecond)
+}
+
+func (l *hostLimiter) currentRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiter_Reserve(t *testing.T) {
+	l := newHostLimiter("hh.ru", HostLimit{RequestsPerSecond: 2, Burst: 2})
+	now := l.last
+
+	// The burst goes out at once, then requests are spaced by the rate.
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, 500*time.Millisecond, l.reserve(now))
+	assert.Equal(t, time.Second, l.reserve(now))
+
+	// Tokens refill over time, at most up to the burst.
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, 500*time.Millisecond, l.reserve(now))
+}
+
+func TestHostLimiter_SlowDown(t *testing.T) {
+	l := newHostLimiter("hh.ru", HostLimit{RequestsPerSecond: 4, Burst: 1})
+
+	l.slowDown()
+	assert.Equal(t, 2.0, l.currentRate())
+	for i := 0; i < 10; i++ {
+		l.slowDown()
+	}
+	assert.Equal(t, 0.25, l.currentRate())
+
+	for i := 0; i < 100; i++ {
+		l.speedUp()
+	}
+	assert.Equal(t, 4.0, l.currentRate())
+}
+
+func TestHostLimiter_Jitter(t *testing.T) {
+	l := newHostLimiter("hh.ru", HostLimit{Jitter: 100 * time.Millisecond})
+
+	for i := 0; i < 20; i++ {
+		d := l.reserve(time.Now())
+		assert.True(t, d >= 0 && d < 100*time.Millisecond, d)
+	}
+}
@@ -0,0 +1,226 @@
+package fetcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsRetry is how soon an unreachable robots.txt is tried again.
+const robotsRetry = time.Minute
+
+// robotsRule is an Allow or Disallow line. Patterns may use * and a final $.
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robots is a parsed robots.txt. A nil *robots allows everything.
+type robots struct {
+	groups []*robotsGroup
+}
+
+func parseRobots(r io.Reader) *robots {
+	rb := &robots{}
+	var group *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				group = &robotsGroup{}
+				rb.groups = append(rb.groups, group)
+				inAgents = true
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+			continue
+		case "allow", "disallow":
+			if group != nil && value != "" {
+				group.rules = append(group.rules, robotsRule{
+					allow:   key == "allow",
+					pattern: value,
+					re:      robotsPattern(value),
+				})
+			}
+		case "crawl-delay":
+			if group != nil {
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					group.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+		inAgents = false
+	}
+
+	return rb
+}
+
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+
+	return regexp.MustCompile(expr)
+}
+
+// group returns the rules for the agent: the groups naming it, or else the
+// groups for every agent.
+func (rb *robots) group(agent string) *robotsGroup {
+	agent = strings.ToLower(agent)
+	matched := &robotsGroup{}
+	wildcard := &robotsGroup{}
+	for _, g := range rb.groups {
+		for _, a := range g.agents {
+			switch a {
+			case agent:
+				matched.rules = append(matched.rules, g.rules...)
+				matched.crawlDelay = max(matched.crawlDelay, g.crawlDelay)
+				matched.agents = append(matched.agents, a)
+			case "*":
+				wildcard.rules = append(wildcard.rules, g.rules...)
+				wildcard.crawlDelay = max(wildcard.crawlDelay, g.crawlDelay)
+			}
+		}
+	}
+	if len(matched.agents) > 0 {
+		return matched
+	}
+
+	return wildcard
+}
+
+// allowed applies the most specific matching rule to the path, where allow
+// wins a tie. Paths no rule matches are allowed.
+func (rb *robots) allowed(agent, path string) bool {
+	if rb == nil {
+		return true
+	}
+
+	allow, length := true, -1
+	for _, rule := range rb.group(agent).rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > length || (len(rule.pattern) == length && rule.allow) {
+			allow, length = rule.allow, len(rule.pattern)
+		}
+	}
+
+	return allow
+}
+
+// robotsEntry caches the robots.txt of a host, or the error loading it.
+type robotsEntry struct {
+	mu      sync.Mutex
+	robots  *robots
+	err     error
+	expires time.Time
+}
+
+// checkRobots returns an ErrDisallowed error when the robots.txt of the
+// host does not allow the configured agent to fetch rawURL.
+func (f *Fetcher) checkRobots(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	rb, err := f.robots(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !rb.allowed(f.config.RobotsAgent, path) {
+		log.Println("robots.txt disallows", rawURL)
+		return &Error{URL: rawURL, Kind: ErrDisallowed}
+	}
+
+	return nil
+}
+
+func (f *Fetcher) robots(ctx context.Context, u *url.URL) (*robots, error) {
+	f.mu.Lock()
+	entry, ok := f.robotsCache[u.Host]
+	if !ok {
+		entry = &robotsEntry{}
+		f.robotsCache[u.Host] = entry
+	}
+	f.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if time.Now().Before(entry.expires) {
+		return entry.robots, entry.err
+	}
+
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	res, err := f.fetch(ctx, robotsURL, nil)
+
+	var fetchErr *Error
+	switch {
+	case err == nil:
+		entry.robots = parseRobots(bytes.NewReader(res.Body))
+		entry.err = nil
+		entry.expires = time.Now().Add(f.config.RobotsTTL)
+		f.limiter(robotsURL).setCrawlDelay(entry.robots.group(f.config.RobotsAgent).crawlDelay)
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case errors.As(err, &fetchErr) && fetchErr.StatusCode >= 400 && fetchErr.StatusCode < 500 &&
+		fetchErr.StatusCode != http.StatusTooManyRequests:
+		// A missing robots.txt allows everything. A rate limited one is
+		// only unavailable for now.
+		entry.robots = nil
+		entry.err = nil
+		entry.expires = time.Now().Add(f.config.RobotsTTL)
+	default:
+		// Pages of the host are not fetched until the robots.txt can be
+		// loaded, but they are not disallowed either: the failure is
+		// transient, so callers retry them later.
+		log.Println("cannot load robots.txt", robotsURL, err)
+		entry.robots = nil
+		entry.err = &Error{URL: robotsURL, Kind: ErrTransient, Err: fmt.Errorf("robots.txt is unavailable: %w", err)}
+		entry.expires = time.Now().Add(robotsRetry)
+	}
+
+	return entry.robots, entry.err
+}
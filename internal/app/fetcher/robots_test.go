@@ -0,0 +1,128 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRobots = `
+# comment
+User-agent: *
+Disallow: /search
+Allow: /search/vacancy
+Disallow: /*.pdf$
+
+User-agent: Vacancy-Parser
+User-agent: other-bot
+Disallow: /admin
+Allow: /admin/public
+Crawl-delay: 2
+`
+
+func TestRobots_Allowed(t *testing.T) {
+	rb := parseRobots(strings.NewReader(testRobots))
+
+	for _, tc := range []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"googlebot", "/vacancy/1", true},
+		{"googlebot", "/search?text=go", false},
+		{"googlebot", "/search/vacancy?text=go", true},
+		{"googlebot", "/files/cv.pdf", false},
+		{"googlebot", "/files/cv.pdf?download=1", true},
+		{"vacancy-parser", "/search?text=go", true},
+		{"vacancy-parser", "/admin/users", false},
+		{"vacancy-parser", "/admin/public", true},
+	} {
+		assert.Equal(t, tc.allowed, rb.allowed(tc.agent, tc.path), tc.agent+" "+tc.path)
+	}
+
+	assert.Equal(t, 2*time.Second, rb.group("vacancy-parser").crawlDelay)
+	assert.Equal(t, time.Duration(0), rb.group("googlebot").crawlDelay)
+
+	var missing *robots
+	assert.True(t, missing.allowed("vacancy-parser", "/admin"))
+}
+
+func TestFetcher_GetRobots(t *testing.T) {
+	robotsCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsCalls++
+			w.Write([]byte("User-agent: vacancy-parser\nDisallow: /private\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	config := testConfig()
+	config.Robots = true
+	config.RobotsAgent = "vacancy-parser"
+	config.RobotsTTL = time.Hour
+	f := New(config)
+
+	_, err := f.Get(context.Background(), ts.URL+"/vacancy/1")
+	assert.NoError(t, err)
+	_, err = f.Get(context.Background(), ts.URL+"/private/1")
+	assert.ErrorIs(t, err, ErrDisallowed)
+	assert.Equal(t, 1, robotsCalls)
+}
+
+func TestFetcher_GetRobotsMissing(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	config := testConfig()
+	config.Robots = true
+	config.RobotsTTL = time.Hour
+
+	_, err := New(config).Get(context.Background(), ts.URL+"/private/1")
+	assert.NoError(t, err)
+}
+
+func TestFetcher_GetRobotsUnavailable(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, http.StatusTooManyRequests} {
+		robotsCalls, pageCalls := 0, 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				robotsCalls++
+				w.WriteHeader(status)
+				return
+			}
+			pageCalls++
+			w.Write([]byte("ok"))
+		}))
+
+		config := testConfig()
+		config.Robots = true
+		config.RobotsTTL = time.Hour
+		f := New(config)
+
+		// The failure is retried by the caller, not taken for a disallow
+		// or for a missing robots.txt.
+		_, err := f.Get(context.Background(), ts.URL+"/vacancy/1")
+		assert.ErrorIs(t, err, ErrTransient, status)
+		assert.NotErrorIs(t, err, ErrDisallowed, status)
+		_, err = f.Get(context.Background(), ts.URL+"/vacancy/2")
+		assert.ErrorIs(t, err, ErrTransient, status)
+		assert.Equal(t, config.MaxAttempts, robotsCalls, status)
+		assert.Equal(t, 0, pageCalls, status)
+
+		ts.Close()
+	}
+}
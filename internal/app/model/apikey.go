@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	APIKeyScopeRead      = "read"
+	APIKeyScopeReadWrite = "read-write"
+	APIKeyScopeWebhooks  = "webhooks"
+)
+
+// APIKey is a long-lived credential for machine clients. Only the hash of
+// the key is stored, the key itself is shown once on creation.
+type APIKey struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID      primitive.ObjectID `bson:"owner_id" json:"ownerId"`
+	Name         string             `bson:"name" json:"name"`
+	Prefix       string             `bson:"prefix" json:"prefix"`
+	Hash         string             `bson:"hash" json:"-"`
+	Scope        string             `bson:"scope" json:"scope"`
+	Quota        int64              `bson:"quota" json:"quota"`
+	WindowStart  time.Time          `bson:"window_start" json:"-"`
+	WindowCount  int64              `bson:"window_count" json:"windowCount"`
+	RequestCount int64              `bson:"request_count" json:"requestCount"`
+	LastUsedAt   *time.Time         `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+	RevokedAt    *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
+
+func (k *APIKey) Validate() error {
+	if k.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if k.Scope != APIKeyScopeRead && k.Scope != APIKeyScopeReadWrite && k.Scope != APIKeyScopeWebhooks {
+		return fmt.Errorf("%w: unknown scope %q", ErrValidation, k.Scope)
+	}
+	if k.Quota < 0 {
+		return fmt.Errorf("%w: quota must not be negative", ErrValidation)
+	}
+
+	return nil
+}
+
+// Roles returns the roles whose permissions the key carries. These are
+// limited to the permissions both its scope and the current roles of its
+// owner grant, so a key loses what its owner loses.
+func (k *APIKey) Roles(ownerRoles []string) []string {
+	scope := []string{RoleUser}
+	switch k.Scope {
+	case APIKeyScopeReadWrite:
+		scope = []string{RoleEditor}
+	case APIKeyScopeWebhooks:
+		scope = []string{RoleIntegration}
+	}
+
+	var roles []string
+	for _, role := range []string{RoleUser, RoleIntegration, RoleEditor} {
+		granted := true
+		for _, perm := range rolePermissions[role] {
+			if !HasPermission(scope, perm) || !HasPermission(ownerRoles, perm) {
+				granted = false
+				break
+			}
+		}
+		if granted {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
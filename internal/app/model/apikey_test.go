@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey_Roles(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		scope      string
+		ownerRoles []string
+		want       []string
+	}{
+		{"read key of a user", model.APIKeyScopeRead, []string{model.RoleUser}, []string{model.RoleUser}},
+		{"read-write key of an editor", model.APIKeyScopeReadWrite, []string{model.RoleEditor}, []string{model.RoleUser, model.RoleIntegration, model.RoleEditor}},
+		{"read-write key of a demoted editor", model.APIKeyScopeReadWrite, []string{model.RoleUser}, []string{model.RoleUser}},
+		{"webhooks key of an admin", model.APIKeyScopeWebhooks, []string{model.RoleAdmin}, []string{model.RoleUser, model.RoleIntegration}},
+		{"webhooks key of a demoted editor", model.APIKeyScopeWebhooks, []string{model.RoleUser}, []string{model.RoleUser}},
+		{"key of an owner without roles", model.APIKeyScopeRead, nil, nil},
+	} {
+		key := &model.APIKey{Scope: tc.scope}
+		roles := key.Roles(tc.ownerRoles)
+		assert.Equal(t, tc.want, roles, tc.name)
+		for _, perm := range []model.Permission{model.PermVacanciesWrite, model.PermWebhooksManage} {
+			if model.HasPermission(roles, perm) {
+				assert.True(t, model.HasPermission(tc.ownerRoles, perm), tc.name)
+			}
+		}
+	}
+}
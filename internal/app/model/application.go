@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StageSaved     = "saved"
+	StageApplied   = "applied"
+	StageInterview = "interview"
+	StageOffer     = "offer"
+	StageRejected  = "rejected"
+)
+
+var stages = []string{StageSaved, StageApplied, StageInterview, StageOffer, StageRejected}
+
+func ValidStage(stage string) bool {
+	for _, s := range stages {
+		if s == stage {
+			return true
+		}
+	}
+
+	return false
+}
+
+type StageChange struct {
+	Stage string    `bson:"stage" json:"stage"`
+	At    time.Time `bson:"at" json:"at"`
+	Note  string    `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// Application is a bookmarked vacancy moving through the user's hiring
+// pipeline. The vacancy is copied so the entry outlives re-crawls.
+type Application struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"userId"`
+	VacancyLink string             `bson:"vacancy_link" json:"vacancyLink"`
+	Vacancy     Vacancy            `bson:"vacancy" json:"vacancy"`
+	Stage       string             `bson:"stage" json:"stage"`
+	Notes       string             `bson:"notes" json:"notes"`
+	History     []StageChange      `bson:"history" json:"history"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+func (a *Application) Validate() error {
+	if a.VacancyLink == "" {
+		return fmt.Errorf("%w: vacancy link is required", ErrValidation)
+	}
+	if !ValidStage(a.Stage) {
+		return fmt.Errorf("%w: unknown stage %q", ErrValidation, a.Stage)
+	}
+
+	return nil
+}
+
+// MoveTo changes the stage and records the change in the history.
+// at defaults to now, so past events can be back-filled.
+func (a *Application) MoveTo(stage string, at time.Time, note string) error {
+	if !ValidStage(stage) {
+		return fmt.Errorf("%w: unknown stage %q", ErrValidation, stage)
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	a.Stage = stage
+	a.History = append(a.History, StageChange{Stage: stage, At: at, Note: note})
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplication_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		app     model.Application
+		isValid bool
+	}{
+		{"valid", model.Application{VacancyLink: "https://hh.ru/vacancy/1", Stage: model.StageSaved}, true},
+		{"no link", model.Application{Stage: model.StageSaved}, false},
+		{"unknown stage", model.Application{VacancyLink: "https://hh.ru/vacancy/1", Stage: "hired"}, false},
+	} {
+		if tc.isValid {
+			assert.NoError(t, tc.app.Validate(), tc.name)
+		} else {
+			assert.ErrorIs(t, tc.app.Validate(), model.ErrValidation, tc.name)
+		}
+	}
+}
+
+func TestApplication_MoveTo(t *testing.T) {
+	app := &model.Application{VacancyLink: "https://hh.ru/vacancy/1"}
+	applied := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, app.MoveTo(model.StageApplied, applied, "sent CV"))
+	require.NoError(t, app.MoveTo(model.StageInterview, time.Time{}, ""))
+	assert.Equal(t, model.StageInterview, app.Stage)
+	require.Len(t, app.History, 2)
+	assert.Equal(t, model.StageChange{Stage: model.StageApplied, At: applied, Note: "sent CV"}, app.History[0])
+	assert.False(t, app.History[1].At.IsZero())
+
+	// An unknown stage leaves the application as it was.
+	assert.ErrorIs(t, app.MoveTo("hired", time.Time{}, ""), model.ErrValidation)
+	assert.Equal(t, model.StageInterview, app.Stage)
+	assert.Len(t, app.History, 2)
+}
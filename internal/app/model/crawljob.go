@@ -0,0 +1,204 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCanceled  = "canceled"
+	// JobBlocked jobs are running but paused, as the site served anti-bot pages.
+	JobBlocked = "blocked"
+)
+
+// MaxCrawlPages limits the listing pages crawled per query.
+const MaxCrawlPages = 100
+
+// CrawlQuery is one search run against every source of a job. Areas,
+// experience, schedule and employment take hh.ru search parameter values.
+type CrawlQuery struct {
+	// Name identifies a configured query and is the tag put on its vacancies.
+	Name       string   `bson:"name,omitempty" json:"name,omitempty" toml:"name"`
+	Text       string   `bson:"text" json:"text" toml:"text"`
+	Areas      []string `bson:"areas,omitempty" json:"areas,omitempty" toml:"areas"`
+	Experience string   `bson:"experience,omitempty" json:"experience,omitempty" toml:"experience"`
+	Schedule   []string `bson:"schedule,omitempty" json:"schedule,omitempty" toml:"schedule"`
+	Employment []string `bson:"employment,omitempty" json:"employment,omitempty" toml:"employment"`
+	// Mode is how hh.ru is crawled: by scraping the site or through its API.
+	Mode string `bson:"mode,omitempty" json:"mode,omitempty" toml:"mode"`
+}
+
+const (
+	// QueryModeHTML scrapes the site, which is the default.
+	QueryModeHTML = "html"
+	// QueryModeAPI uses the hh.ru JSON API.
+	QueryModeAPI = "api"
+)
+
+var (
+	crawlExperience = []string{"noExperience", "between1And3", "between3And6", "moreThan6"}
+	crawlSchedule   = []string{"fullDay", "shift", "flexible", "remote", "flyInFlyOut"}
+	crawlEmployment = []string{"full", "part", "project", "volunteer", "probation"}
+)
+
+// EffectiveMode is the mode of the query, html unless set.
+func (q *CrawlQuery) EffectiveMode() string {
+	if q.Mode == "" {
+		return QueryModeHTML
+	}
+
+	return q.Mode
+}
+
+// Tag is put on every vacancy the query finds: its name, or its text.
+func (q *CrawlQuery) Tag() string {
+	if q.Name != "" {
+		return q.Name
+	}
+
+	return q.Text
+}
+
+func (q *CrawlQuery) Validate() error {
+	if q.Text == "" {
+		return fmt.Errorf("%w: query text is required", ErrValidation)
+	}
+	for _, area := range q.Areas {
+		if _, err := strconv.Atoi(area); err != nil {
+			return fmt.Errorf("%w: area %q must be a numeric region id", ErrValidation, area)
+		}
+	}
+	if q.Experience != "" && !slices.Contains(crawlExperience, q.Experience) {
+		return fmt.Errorf("%w: unknown experience %q", ErrValidation, q.Experience)
+	}
+	for _, s := range q.Schedule {
+		if !slices.Contains(crawlSchedule, s) {
+			return fmt.Errorf("%w: unknown schedule %q", ErrValidation, s)
+		}
+	}
+	for _, e := range q.Employment {
+		if !slices.Contains(crawlEmployment, e) {
+			return fmt.Errorf("%w: unknown employment %q", ErrValidation, e)
+		}
+	}
+	if q.Mode != "" && q.Mode != QueryModeHTML && q.Mode != QueryModeAPI {
+		return fmt.Errorf("%w: unknown mode %q", ErrValidation, q.Mode)
+	}
+
+	return nil
+}
+
+// CrawlCounters track the progress of a crawl job.
+type CrawlCounters struct {
+	// Listed vacancy links found on listing pages.
+	Listed int64 `bson:"listed" json:"listed"`
+	// Fetched vacancy pages.
+	Fetched int64 `bson:"fetched" json:"fetched"`
+	// Parsed vacancies out of the fetched pages.
+	Parsed int64 `bson:"parsed" json:"parsed"`
+	// Skipped pages which held no vacancy.
+	Skipped int64 `bson:"skipped" json:"skipped"`
+	// Known vacancies an incremental crawl did not fetch again.
+	Known int64 `bson:"known" json:"known"`
+	// Blocked requests answered with anti-bot or login pages.
+	Blocked int64 `bson:"blocked" json:"blocked"`
+	// Failed fetches and writes.
+	Failed   int64 `bson:"failed" json:"failed"`
+	Inserted int64 `bson:"inserted" json:"inserted"`
+	Updated  int64 `bson:"updated" json:"updated"`
+	Closed   int64 `bson:"closed" json:"closed"`
+}
+
+type CrawlJob struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Sources []string           `bson:"sources" json:"sources"`
+	Queries []CrawlQuery       `bson:"queries" json:"queries"`
+	Pages   int                `bson:"pages" json:"pages"`
+	// Incremental jobs list the newest vacancies first and stop at known ones.
+	Incremental bool          `bson:"incremental,omitempty" json:"incremental,omitempty"`
+	Status      string        `bson:"status" json:"status"`
+	Counters    CrawlCounters `bson:"counters" json:"counters"`
+	// Selectors is the version of the parser selectors the job ran with.
+	Selectors string          `bson:"selectors,omitempty" json:"selectors,omitempty"`
+	Progress  []CrawlProgress `bson:"progress,omitempty" json:"progress,omitempty"`
+	Quality   ParseQuality    `bson:"quality" json:"quality"`
+	// ModeQuality splits Quality by query mode. Drift is judged per mode.
+	ModeQuality []ParseQuality `bson:"mode_quality,omitempty" json:"modeQuality,omitempty"`
+	// Degraded lists the fields whose fill rate collapsed in this job.
+	Degraded []FieldDrift `bson:"degraded,omitempty" json:"degraded,omitempty"`
+	Error    string       `bson:"error,omitempty" json:"error,omitempty"`
+	// BlockedUntil is when a blocked job resumes.
+	BlockedUntil *time.Time          `bson:"blocked_until,omitempty" json:"blockedUntil,omitempty"`
+	CreatedBy    *primitive.ObjectID `bson:"created_by,omitempty" json:"createdBy,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at" json:"createdAt"`
+	StartedAt    *time.Time          `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt   *time.Time          `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
+
+func (j *CrawlJob) Validate() error {
+	if len(j.Sources) == 0 {
+		return fmt.Errorf("%w: at least one source is required", ErrValidation)
+	}
+	if len(j.Queries) == 0 {
+		return fmt.Errorf("%w: at least one query is required", ErrValidation)
+	}
+	for i := range j.Queries {
+		if err := j.Queries[i].Validate(); err != nil {
+			return err
+		}
+	}
+	if j.Pages < 1 || j.Pages > MaxCrawlPages {
+		return fmt.Errorf("%w: pages must be between 1 and %d", ErrValidation, MaxCrawlPages)
+	}
+
+	return nil
+}
+
+// QueryProgress returns the progress of the query of the source, adding it
+// when the job has not started it yet.
+func (j *CrawlJob) QueryProgress(source string, query CrawlQuery) *CrawlProgress {
+	for i := range j.Progress {
+		if j.Progress[i].Source == source && j.Progress[i].Query == query.Tag() {
+			return &j.Progress[i]
+		}
+	}
+	j.Progress = append(j.Progress, CrawlProgress{Source: source, Query: query.Tag()})
+
+	return &j.Progress[len(j.Progress)-1]
+}
+
+// QualityOf returns the parse quality of the queries in the mode, adding it
+// when the job has not parsed any of them yet.
+func (j *CrawlJob) QualityOf(mode string) *ParseQuality {
+	for i := range j.ModeQuality {
+		if j.ModeQuality[i].Mode == mode {
+			return &j.ModeQuality[i]
+		}
+	}
+	j.ModeQuality = append(j.ModeQuality, ParseQuality{Mode: mode})
+
+	return &j.ModeQuality[len(j.ModeQuality)-1]
+}
+
+// FetchFailures counts the listed vacancies of every query which could not be fetched.
+func (j *CrawlJob) FetchFailures() int64 {
+	var failures int64
+	for _, p := range j.Progress {
+		failures += p.FetchFailures
+	}
+
+	return failures
+}
+
+// Finished reports whether the job has reached a final status.
+func (j *CrawlJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCanceled
+}
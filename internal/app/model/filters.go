@@ -1,5 +1,134 @@
 package model
 
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filters describes a vacancy search. Empty fields match every vacancy.
 type Filters struct {
-	hardSkills []string
+	// Text words must all be present in the title, company or hard skills.
+	Text         string   `bson:"text,omitempty" json:"text,omitempty"`
+	HardSkills   []string `bson:"hard_skills,omitempty" json:"hardSkills,omitempty"`
+	Location     string   `bson:"location,omitempty" json:"location,omitempty"`
+	MainLanguage string   `bson:"main_language,omitempty" json:"mainLanguage,omitempty"`
+	Remote       bool     `bson:"remote,omitempty" json:"remote,omitempty"`
+	SalaryFrom   int64    `bson:"salary_from,omitempty" json:"salaryFrom,omitempty"`
+	// Tag of the crawl query which found the vacancy.
+	Tag string `bson:"tag,omitempty" json:"tag,omitempty"`
+}
+
+// RemoteMarkers are looked for in the title and location of remote vacancies.
+var RemoteMarkers = []string{"удален", "удалён", "remote"}
+
+func (f *Filters) Empty() bool {
+	return f.Text == "" && len(f.HardSkills) == 0 && f.Location == "" &&
+		f.MainLanguage == "" && !f.Remote && f.SalaryFrom == 0 && f.Tag == ""
+}
+
+// Match reports whether the vacancy satisfies every set filter.
+func (f *Filters) Match(v *Vacancy) bool {
+	if f.MainLanguage != "" && !strings.EqualFold(f.MainLanguage, v.MainLanguage) {
+		return false
+	}
+
+	if f.Tag != "" && !hasFold(v.Tags, f.Tag) {
+		return false
+	}
+
+	if f.Location != "" && !containsFold(v.Location, f.Location) {
+		return false
+	}
+
+	if len(f.HardSkills) > 0 {
+		for _, want := range f.HardSkills {
+			if !hasFold(v.HardSkills, want) {
+				return false
+			}
+		}
+	}
+
+	if f.Text != "" {
+		haystack := strings.Join(append([]string{v.Title, v.Company, v.MainLanguage}, v.HardSkills...), " ")
+		for _, word := range strings.Fields(f.Text) {
+			if !containsFold(haystack, word) {
+				return false
+			}
+		}
+	}
+
+	if f.Remote && !isRemote(v) {
+		return false
+	}
+
+	if f.SalaryFrom > 0 {
+		from, to := ParseSalary(v.Salary)
+		if max(from, to) < f.SalaryFrom {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isRemote(v *Vacancy) bool {
+	for _, marker := range RemoteMarkers {
+		if containsFold(v.Title, marker) || containsFold(v.Location, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasFold(list []string, want string) bool {
+	for _, s := range list {
+		if strings.EqualFold(strings.TrimSpace(s), strings.TrimSpace(want)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(strings.TrimSpace(substr)))
+}
+
+var salaryNumberRe = regexp.MustCompile(`\d[\d\s\x{00a0}\x{202f}]*\d|\d`)
+
+// ParseSalary extracts the bounds from an hh.ru salary string such as
+// "от 300 000 до 400 000 ₽ на руки". A missing bound is returned as 0.
+func ParseSalary(salary string) (from, to int64) {
+	matches := salaryNumberRe.FindAllStringIndex(salary, 2)
+	if len(matches) == 0 {
+		return 0, 0
+	}
+
+	numbers := make([]int64, 0, len(matches))
+	for _, m := range matches {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, salary[m[0]:m[1]])
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			return 0, 0
+		}
+		numbers = append(numbers, n)
+	}
+
+	if len(numbers) == 2 {
+		return numbers[0], numbers[1]
+	}
+
+	prefix := strings.ToLower(salary[:matches[0][0]])
+	if strings.Contains(prefix, "до") && !strings.Contains(prefix, "от") {
+		return 0, numbers[0]
+	}
+
+	return numbers[0], 0
 }
@@ -0,0 +1,92 @@
+package model_test
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSalary(t *testing.T) {
+	testCases := []struct {
+		salary string
+		from   int64
+		to     int64
+	}{
+		{"от 300 000 ₽ на руки", 300000, 0},
+		{"до 250 000 ₽ до вычета налогов", 0, 250000},
+		{"от 200 000 до 350 000 ₽ на руки", 200000, 350000},
+		{"150 000 – 200 000 ₽", 150000, 200000},
+		{"Уровень дохода не указан", 0, 0},
+		{"", 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.salary, func(t *testing.T) {
+			from, to := model.ParseSalary(tc.salary)
+			assert.Equal(t, tc.from, from)
+			assert.Equal(t, tc.to, to)
+		})
+	}
+}
+
+func TestFilters_Match(t *testing.T) {
+	v := &model.Vacancy{
+		Title:        "Senior Go developer (удаленно)",
+		Company:      "Example",
+		Location:     "Москва",
+		HardSkills:   []string{"Go", "PostgreSQL", "Kubernetes"},
+		Salary:       "от 300 000 до 400 000 ₽ на руки",
+		MainLanguage: "Go",
+		Tags:         []string{"go-remote"},
+	}
+
+	testCases := []struct {
+		name    string
+		filters model.Filters
+		match   bool
+	}{
+		{"empty", model.Filters{}, true},
+		{"go remote from 300k", model.Filters{MainLanguage: "go", Remote: true, SalaryFrom: 300000}, true},
+		{"salary above range", model.Filters{SalaryFrom: 500000}, false},
+		{"skills", model.Filters{HardSkills: []string{"postgresql", "go"}}, true},
+		{"missing skill", model.Filters{HardSkills: []string{"Rust"}}, false},
+		{"text", model.Filters{Text: "senior kubernetes"}, true},
+		{"text missing word", model.Filters{Text: "junior"}, false},
+		{"location", model.Filters{Location: "моск"}, true},
+		{"other language", model.Filters{MainLanguage: "JavaScript"}, false},
+		{"tag", model.Filters{Tag: "Go-Remote"}, true},
+		{"other tag", model.Filters{Tag: "javascript-moscow"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.match, tc.filters.Match(v))
+		})
+	}
+}
+
+func TestCrawlQuery_Validate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query model.CrawlQuery
+		valid bool
+	}{
+		{"text only", model.CrawlQuery{Text: "Go"}, true},
+		{"all filters", model.CrawlQuery{Text: "Go", Areas: []string{"1", "2"}, Experience: "between1And3", Schedule: []string{"remote"}, Employment: []string{"full"}}, true},
+		{"no text", model.CrawlQuery{Areas: []string{"1"}}, false},
+		{"area name", model.CrawlQuery{Text: "Go", Areas: []string{"Moscow"}}, false},
+		{"unknown experience", model.CrawlQuery{Text: "Go", Experience: "senior"}, false},
+		{"unknown schedule", model.CrawlQuery{Text: "Go", Schedule: []string{"hybrid"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, tc.query.Validate())
+			} else {
+				assert.ErrorIs(t, tc.query.Validate(), model.ErrValidation)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	FrontierPending  = "pending"
+	FrontierInFlight = "in_flight"
+	FrontierDone     = "done"
+	FrontierFailed   = "failed"
+)
+
+// MaxFetchAttempts limits how often a resumed job fetches a failed link again.
+const MaxFetchAttempts = 3
+
+// FrontierItem is a vacancy link a crawl job has listed and has to fetch.
+type FrontierItem struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobID     primitive.ObjectID `bson:"job_id" json:"jobId"`
+	Source    string             `bson:"source" json:"source"`
+	Query     string             `bson:"query" json:"query"`
+	Link      string             `bson:"link" json:"link"`
+	State     string             `bson:"state" json:"state"`
+	Attempts  int                `bson:"attempts" json:"attempts"`
+	Error     string             `bson:"error,omitempty" json:"error,omitempty"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// CrawlProgress is how far a job got with one query of one source, so an
+// interrupted job can resume listing at the next page.
+type CrawlProgress struct {
+	Source   string `bson:"source" json:"source"`
+	Query    string `bson:"query" json:"query"`
+	NextPage int    `bson:"next_page" json:"nextPage"`
+	Listed   bool   `bson:"listed" json:"listed"`
+	// Exhausted is set when the listing ran out before the page limit, so
+	// it held every vacancy of the query.
+	Exhausted bool `bson:"exhausted" json:"exhausted"`
+	// FetchFailures counts the listed vacancies which could not be fetched.
+	FetchFailures int64 `bson:"fetch_failures" json:"fetchFailures"`
+	Done          bool  `bson:"done" json:"done"`
+}
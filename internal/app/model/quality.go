@@ -0,0 +1,105 @@
+package model
+
+// Vacancy fields whose fill rates are tracked.
+const (
+	FieldTitle      = "title"
+	FieldLocation   = "location"
+	FieldCompany    = "company"
+	FieldSalary     = "salary"
+	FieldHardSkills = "hardSkills"
+	FieldDate       = "date"
+	FieldExperience = "experience"
+)
+
+// QualityFields lists the tracked fields.
+var QualityFields = []string{FieldTitle, FieldLocation, FieldCompany, FieldSalary, FieldHardSkills, FieldDate, FieldExperience}
+
+// ParseQuality counts how many of the parsed vacancies had each field
+// filled. Pages which failed to parse count as vacancies without any field,
+// so a parser which stops finding titles shows up as drift.
+type ParseQuality struct {
+	// Mode is the query mode of the vacancies, as the site and the API fill
+	// fields differently. It is empty for the quality of a whole job.
+	Mode   string           `bson:"mode,omitempty" json:"mode,omitempty"`
+	Parsed int64            `bson:"parsed" json:"parsed"`
+	Filled map[string]int64 `bson:"filled" json:"filled"`
+}
+
+// Add counts the filled fields of a parsed vacancy.
+func (q *ParseQuality) Add(v *Vacancy) {
+	if q.Filled == nil {
+		q.Filled = make(map[string]int64, len(QualityFields))
+	}
+	q.Parsed++
+
+	for field, filled := range map[string]bool{
+		FieldTitle:      v.Title != "",
+		FieldLocation:   v.Location != "",
+		FieldCompany:    v.Company != "",
+		FieldSalary:     v.Salary != "",
+		FieldHardSkills: len(v.HardSkills) > 0,
+		FieldDate:       v.Date != "",
+		FieldExperience: v.Experience != "",
+	} {
+		if filled {
+			q.Filled[field]++
+		}
+	}
+}
+
+// AddFailed counts a page which failed to parse.
+func (q *ParseQuality) AddFailed() {
+	q.Parsed++
+}
+
+// Rate returns the share of the parsed vacancies with the field filled.
+func (q *ParseQuality) Rate(field string) float64 {
+	if q.Parsed == 0 {
+		return 0
+	}
+
+	return float64(q.Filled[field]) / float64(q.Parsed)
+}
+
+// FieldDrift is a field whose fill rate collapsed against the baseline.
+type FieldDrift struct {
+	Mode     string  `bson:"mode,omitempty" json:"mode,omitempty"`
+	Field    string  `bson:"field" json:"field"`
+	Rate     float64 `bson:"rate" json:"rate"`
+	Baseline float64 `bson:"baseline" json:"baseline"`
+}
+
+// DetectDrift compares the fill rates of a crawl with the average rates of
+// earlier ones and returns the fields which fell below maxDrop of their
+// baseline. Crawls of less than minSample vacancies are neither judged nor
+// counted in the baseline.
+func DetectDrift(current ParseQuality, baseline []ParseQuality, minSample int64, maxDrop float64) []FieldDrift {
+	if current.Parsed < minSample {
+		return nil
+	}
+
+	var samples []ParseQuality
+	for _, q := range baseline {
+		if q.Parsed >= minSample {
+			samples = append(samples, q)
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+
+	var drifts []FieldDrift
+	for _, field := range QualityFields {
+		var sum float64
+		for _, q := range samples {
+			sum += q.Rate(field)
+		}
+		base := sum / float64(len(samples))
+
+		if rate := current.Rate(field); rate < base*maxDrop {
+			drifts = append(drifts, FieldDrift{Mode: current.Mode, Field: field, Rate: rate, Baseline: base})
+		}
+	}
+
+	return drifts
+}
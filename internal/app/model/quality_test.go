@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func quality(parsed int64, filled map[string]int64) ParseQuality {
+	return ParseQuality{Parsed: parsed, Filled: filled}
+}
+
+func TestParseQuality_Add(t *testing.T) {
+	var q ParseQuality
+	q.Add(&Vacancy{Title: "Go developer", Salary: "300 000 ₽", HardSkills: []string{"Go"}})
+	q.Add(&Vacancy{Title: "PHP developer"})
+
+	assert.Equal(t, int64(2), q.Parsed)
+	assert.Equal(t, 1.0, q.Rate(FieldTitle))
+	assert.Equal(t, 0.5, q.Rate(FieldSalary))
+	assert.Equal(t, 0.5, q.Rate(FieldHardSkills))
+	assert.Equal(t, 0.0, q.Rate(FieldCompany))
+
+	// Pages which failed to parse have no field filled.
+	q.AddFailed()
+	q.AddFailed()
+	assert.Equal(t, int64(4), q.Parsed)
+	assert.Equal(t, 0.5, q.Rate(FieldTitle))
+}
+
+func TestCrawlJob_QualityOf(t *testing.T) {
+	job := &CrawlJob{}
+	job.QualityOf(QueryModeHTML).Add(&Vacancy{Title: "Go developer"})
+	job.QualityOf(QueryModeAPI).AddFailed()
+	job.QualityOf(QueryModeHTML).AddFailed()
+
+	assert.Len(t, job.ModeQuality, 2)
+	assert.Equal(t, ParseQuality{Mode: QueryModeHTML, Parsed: 2, Filled: map[string]int64{FieldTitle: 1}}, *job.QualityOf(QueryModeHTML))
+	assert.Equal(t, int64(1), job.QualityOf(QueryModeAPI).Parsed)
+}
+
+func TestDetectDrift(t *testing.T) {
+	baseline := []ParseQuality{
+		quality(100, map[string]int64{FieldTitle: 100, FieldSalary: 60, FieldCompany: 90}),
+		quality(100, map[string]int64{FieldTitle: 100, FieldSalary: 40, FieldCompany: 90}),
+		// Too small to count.
+		quality(5, map[string]int64{}),
+	}
+
+	drifts := DetectDrift(quality(50, map[string]int64{FieldTitle: 50, FieldSalary: 10, FieldCompany: 44}), baseline, 20, 0.5)
+	assert.Equal(t, []FieldDrift{{Field: FieldSalary, Rate: 0.2, Baseline: 0.5}}, drifts)
+
+	// Smaller drops are fine.
+	assert.Empty(t, DetectDrift(quality(50, map[string]int64{FieldTitle: 50, FieldSalary: 25, FieldCompany: 45}), baseline, 20, 0.5))
+	// Drifts name the mode of the quality.
+	current := quality(50, map[string]int64{FieldTitle: 0, FieldSalary: 25, FieldCompany: 45})
+	current.Mode = QueryModeAPI
+	assert.Equal(t, []FieldDrift{{Mode: QueryModeAPI, Field: FieldTitle, Rate: 0, Baseline: 1}}, DetectDrift(current, baseline, 20, 0.5))
+	// Neither are small crawls or crawls without a baseline.
+	assert.Empty(t, DetectDrift(quality(10, map[string]int64{}), baseline, 20, 0.5))
+	assert.Empty(t, DetectDrift(quality(50, map[string]int64{}), nil, 20, 0.5))
+}
@@ -0,0 +1,46 @@
+package model
+
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+	// RoleIntegration reads vacancies and manages webhooks, it is meant for
+	// API keys which feed external systems.
+	RoleIntegration = "integration"
+)
+
+type Permission string
+
+const (
+	PermVacanciesRead   Permission = "vacancies:read"
+	PermVacanciesWrite  Permission = "vacancies:write"
+	PermVacanciesManage Permission = "vacancies:manage"
+	PermUsersManage     Permission = "users:manage"
+	PermCrawlManage     Permission = "crawl:manage"
+	PermWebhooksManage  Permission = "webhooks:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleUser:        {PermVacanciesRead},
+	RoleEditor:      {PermVacanciesRead, PermVacanciesWrite, PermWebhooksManage},
+	RoleAdmin:       {PermVacanciesRead, PermVacanciesWrite, PermVacanciesManage, PermUsersManage, PermCrawlManage, PermWebhooksManage},
+	RoleIntegration: {PermVacanciesRead, PermWebhooksManage},
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether any of the roles grants the permission.
+func HasPermission(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedSearch is a named filter set a user wants to be notified about.
+type SavedSearch struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"userId"`
+	Name      string             `bson:"name" json:"name"`
+	Filters   Filters            `bson:"filters" json:"filters"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+func (s *SavedSearch) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if s.Filters.Empty() {
+		return fmt.Errorf("%w: at least one filter is required", ErrValidation)
+	}
+	if s.Filters.SalaryFrom < 0 {
+		return fmt.Errorf("%w: salary must not be negative", ErrValidation)
+	}
+
+	return nil
+}
+
+// SearchMatch is an inbox entry: a vacancy which matched a saved search.
+// The vacancy is copied so the entry stays readable after re-crawls.
+type SearchMatch struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"userId"`
+	SearchID   primitive.ObjectID `bson:"search_id" json:"searchId"`
+	SearchName string             `bson:"search_name" json:"searchName"`
+	Vacancy    Vacancy            `bson:"vacancy" json:"vacancy"`
+	Read       bool               `bson:"read" json:"read"`
+	Emailed    bool               `bson:"emailed" json:"emailed"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Schedule is the run history of a periodic crawl configured in the scheduler.
+type Schedule struct {
+	Name       string              `bson:"name" json:"name"`
+	Spec       string              `bson:"spec" json:"spec"`
+	Running    bool                `bson:"running" json:"running"`
+	LastRunAt  *time.Time          `bson:"last_run_at,omitempty" json:"lastRunAt,omitempty"`
+	LastJobID  *primitive.ObjectID `bson:"last_job_id,omitempty" json:"lastJobId,omitempty"`
+	LastStatus string              `bson:"last_status,omitempty" json:"lastStatus,omitempty"`
+	NextRunAt  *time.Time          `bson:"next_run_at,omitempty" json:"nextRunAt,omitempty"`
+	// SkippedRuns counts activations dropped because the previous run was still going.
+	SkippedRuns int64 `bson:"skipped_runs" json:"skippedRuns"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "testing"
+
+func TestUser(t *testing.T) *User {
+	t.Helper()
+
+	return &User{
+		Email:    "user@example.org",
+		Name:     "Test User",
+		Password: "password",
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UnparsedPage is an archived vacancy page which could not be parsed. It is
+// kept so a reparse with fixed parsers can recover the vacancy.
+type UnparsedPage struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Link string             `bson:"link" json:"link"`
+	// Tag of the crawl query which listed the vacancy.
+	Tag string `bson:"tag" json:"tag"`
+	// Language the vacancy was searched for, passed on to the parser.
+	Language  string    `bson:"language" json:"language"`
+	Raw       RawPage   `bson:"raw" json:"raw"`
+	Error     string    `bson:"error" json:"error"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
@@ -1,6 +1,140 @@
 package model
 
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// ErrValidation is wrapped by every error returned from Validate.
+var ErrValidation = errors.New("validation failed")
+
 type User struct {
-	Email    string
-	Password string
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email        string             `bson:"email" json:"email"`
+	Name         string             `bson:"name" json:"name"`
+	Password     string             `bson:"-" json:"password,omitempty"`
+	PasswordHash string             `bson:"password_hash" json:"-"`
+	Roles        []string           `bson:"roles" json:"roles"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+	// DigestFrequency is how often new saved search matches are emailed.
+	DigestFrequency string     `bson:"digest_frequency" json:"digestFrequency"`
+	LastDigestAt    *time.Time `bson:"last_digest_at,omitempty" json:"lastDigestAt,omitempty"`
+	// TelegramChatID is set once the user links the account in the bot.
+	TelegramChatID      int64      `bson:"telegram_chat_id,omitempty" json:"telegramChatId,omitempty"`
+	TelegramLinkCode    string     `bson:"telegram_link_code,omitempty" json:"-"`
+	TelegramLinkExpires *time.Time `bson:"telegram_link_expires,omitempty" json:"-"`
+}
+
+// Validate checks the email and, when it is set, the plaintext password.
+// A user loaded from the store has no plaintext password, only its hash.
+func (u *User) Validate() error {
+	u.Email = NormalizeEmail(u.Email)
+
+	addr, err := mail.ParseAddress(u.Email)
+	if err != nil || addr.Address != u.Email {
+		return fmt.Errorf("%w: invalid email %q", ErrValidation, u.Email)
+	}
+
+	if u.Password == "" && u.PasswordHash == "" {
+		return fmt.Errorf("%w: password is required", ErrValidation)
+	}
+	if u.Password != "" && len(u.Password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrValidation, minPasswordLength)
+	}
+
+	if !ValidDigestFrequency(u.DigestFrequency) {
+		return fmt.Errorf("%w: unknown digest frequency %q", ErrValidation, u.DigestFrequency)
+	}
+
+	for _, role := range u.Roles {
+		if !ValidRole(role) {
+			return fmt.Errorf("%w: unknown role %q", ErrValidation, role)
+		}
+	}
+
+	return nil
+}
+
+// BeforeCreate hashes the plaintext password and fills creation defaults.
+func (u *User) BeforeCreate() error {
+	if err := u.HashPassword(); err != nil {
+		return err
+	}
+
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now().UTC()
+	}
+	if len(u.Roles) == 0 {
+		u.Roles = []string{RoleUser}
+	}
+	if u.DigestFrequency == "" {
+		u.DigestFrequency = DigestDaily
+	}
+
+	return nil
+}
+
+// HashPassword replaces the plaintext password with its bcrypt hash.
+func (u *User) HashPassword() error {
+	if u.Password == "" {
+		return nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("cannot hash password: %w", err)
+	}
+
+	u.PasswordHash = string(hash)
+	u.Sanitize()
+
+	return nil
+}
+
+// Sanitize drops the plaintext password so it never leaves the process.
+func (u *User) Sanitize() {
+	u.Password = ""
+}
+
+// ComparePassword reports whether password matches the stored hash.
+func (u *User) ComparePassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// ValidDigestFrequency accepts the empty value, which stands for the default.
+func ValidDigestFrequency(frequency string) bool {
+	switch frequency {
+	case "", DigestOff, DigestDaily, DigestWeekly:
+		return true
+	}
+
+	return false
+}
+
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
 }
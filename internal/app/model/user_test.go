@@ -0,0 +1,118 @@
+package model_test
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		u       func() *model.User
+		isValid bool
+	}{
+		{
+			name: "valid",
+			u: func() *model.User {
+				return model.TestUser(t)
+			},
+			isValid: true,
+		},
+		{
+			name: "email is normalized",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Email = "  User@Example.ORG "
+				return u
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid email",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Email = "invalid"
+				return u
+			},
+			isValid: false,
+		},
+		{
+			name: "email with display name",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Email = "User <user@example.org>"
+				return u
+			},
+			isValid: false,
+		},
+		{
+			name: "empty password",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Password = ""
+				return u
+			},
+			isValid: false,
+		},
+		{
+			name: "short password",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Password = "short"
+				return u
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown role",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Roles = []string{"root"}
+				return u
+			},
+			isValid: false,
+		},
+		{
+			name: "stored hash without password",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Password = ""
+				u.PasswordHash = "hash"
+				return u
+			},
+			isValid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.u().Validate())
+			} else {
+				assert.ErrorIs(t, tc.u().Validate(), model.ErrValidation)
+			}
+		})
+	}
+}
+
+func TestUser_BeforeCreate(t *testing.T) {
+	u := model.TestUser(t)
+	assert.NoError(t, u.BeforeCreate())
+	assert.NotEmpty(t, u.PasswordHash)
+	assert.Empty(t, u.Password)
+	assert.False(t, u.CreatedAt.IsZero())
+	assert.Equal(t, []string{"user"}, u.Roles)
+	assert.True(t, u.ComparePassword("password"))
+	assert.False(t, u.ComparePassword("wrong"))
+}
+
+func TestHasPermission(t *testing.T) {
+	assert.True(t, model.HasPermission([]string{model.RoleUser}, model.PermVacanciesRead))
+	assert.False(t, model.HasPermission([]string{model.RoleUser}, model.PermVacanciesWrite))
+	assert.True(t, model.HasPermission([]string{model.RoleUser, model.RoleEditor}, model.PermVacanciesWrite))
+	assert.False(t, model.HasPermission([]string{model.RoleEditor}, model.PermUsersManage))
+	assert.True(t, model.HasPermission([]string{model.RoleAdmin}, model.PermCrawlManage))
+	assert.False(t, model.HasPermission(nil, model.PermVacanciesRead))
+}
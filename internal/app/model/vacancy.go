@@ -1,5 +1,12 @@
 package model
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
 type Vacancy struct {
 	Title        string   `json:"title"`
 	Link         string   `json:"link"`
@@ -11,4 +18,53 @@ type Vacancy struct {
 	Salary       string   `json:"salary"`
 	Experience   string   `json:"experience"`
 	MainLanguage string   `json:"mainLanguage"`
+	// Tags of the crawl queries which found the vacancy.
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+
+	// Fingerprint changes when any of the fields above change.
+	Fingerprint string     `json:"-" bson:"fingerprint,omitempty"`
+	Closed      bool       `json:"closed" bson:"closed"`
+	ClosedAt    *time.Time `json:"closedAt,omitempty" bson:"closed_at,omitempty"`
+	FirstSeenAt time.Time  `json:"firstSeenAt" bson:"first_seen_at,omitempty"`
+	LastSeenAt  time.Time  `json:"lastSeenAt" bson:"last_seen_at,omitempty"`
+	// LastFetchedAt is when the vacancy page was last fetched and parsed,
+	// while LastSeenAt also moves when a listing merely shows the vacancy.
+	LastFetchedAt time.Time `json:"lastFetchedAt" bson:"last_fetched_at,omitempty"`
+	// Raw is the archived page the vacancy was parsed from.
+	Raw *RawPage `json:"-" bson:"raw,omitempty"`
+	// SalaryMax is the upper bound of the salary, kept to filter by salary
+	// in the store.
+	SalaryMax int64 `json:"-" bson:"salary_max"`
+}
+
+// RawPage refers to a page in the archive.
+type RawPage struct {
+	Hash   string `bson:"hash"`
+	Source string `bson:"source"`
+	// Format of the page, as sources may load vacancies in several ones.
+	Format    string    `bson:"format,omitempty"`
+	FetchedAt time.Time `bson:"fetched_at"`
+}
+
+const (
+	RawFormatHTML = "html"
+	RawFormatJSON = "json"
+)
+
+// SetSalaryMax fills SalaryMax from the salary string.
+func (v *Vacancy) SetSalaryMax() {
+	from, to := ParseSalary(v.Salary)
+	v.SalaryMax = max(from, to)
+}
+
+// ComputeFingerprint hashes the crawled content of the vacancy.
+func (v *Vacancy) ComputeFingerprint() string {
+	h := sha256.New()
+	for _, field := range []string{v.Title, v.Link, v.Location, v.Company, strings.Join(v.HardSkills, "\x1f"),
+		v.Site, v.Date, v.Salary, v.Experience, v.MainLanguage} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
 }
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription of an external URL to vacancy and crawl events.
+// Filters, when set, limit vacancy events to matching vacancies.
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID   primitive.ObjectID `bson:"owner_id" json:"ownerId"`
+	URL       string             `bson:"url" json:"url"`
+	Events    []string           `bson:"events" json:"events"`
+	Filters   *Filters           `bson:"filters,omitempty" json:"filters,omitempty"`
+	Secret    string             `bson:"secret" json:"secret"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+func (h *Webhook) Validate() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrValidation)
+	}
+	if len(h.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrValidation)
+	}
+	if h.Filters != nil && h.Filters.SalaryFrom < 0 {
+		return fmt.Errorf("%w: salary must not be negative", ErrValidation)
+	}
+
+	return nil
+}
+
+func (h *Webhook) Subscribed(event string) bool {
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery is one attempt series of sending an event to a webhook.
+// The payload is stored as sent so a delivery can be replayed unchanged.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	WebhookID      primitive.ObjectID  `bson:"webhook_id" json:"webhookId"`
+	Event          string              `bson:"event" json:"event"`
+	Payload        string              `bson:"payload" json:"payload"`
+	ReplayOf       *primitive.ObjectID `bson:"replay_of,omitempty" json:"replayOf,omitempty"`
+	Status         string              `bson:"status" json:"status"`
+	Attempts       int                 `bson:"attempts" json:"attempts"`
+	ResponseStatus int                 `bson:"response_status,omitempty" json:"responseStatus,omitempty"`
+	LastError      string              `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt  time.Time           `bson:"next_attempt_at" json:"nextAttemptAt"`
+	CreatedAt      time.Time           `bson:"created_at" json:"createdAt"`
+	UpdatedAt      time.Time           `bson:"updated_at" json:"updatedAt"`
+}
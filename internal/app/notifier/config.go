@@ -0,0 +1,25 @@
+package notifier
+
+import "time"
+
+type Config struct {
+	Enabled  bool   `toml:"enabled"`
+	SMTPHost string `toml:"smtp_host"`
+	SMTPPort int    `toml:"smtp_port"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	From     string `toml:"from"`
+	// BaseURL is the public address of the API, used for unsubscribe links.
+	BaseURL       string        `toml:"base_url"`
+	CheckInterval time.Duration `toml:"check_interval"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		SMTPHost:      "localhost",
+		SMTPPort:      25,
+		From:          "vacancy-parser@localhost",
+		BaseURL:       "http://localhost:8080",
+		CheckInterval: 10 * time.Minute,
+	}
+}
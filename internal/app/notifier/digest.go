@@ -0,0 +1,118 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+	"vacancy-parser/internal/app/model"
+)
+
+// Digest is the data the email templates are rendered with.
+type Digest struct {
+	Name           string
+	Frequency      string
+	Groups         []DigestGroup
+	Total          int
+	UnsubscribeURL string
+}
+
+// DigestGroup holds the matches of one saved search.
+type DigestGroup struct {
+	SearchName string
+	Vacancies  []model.Vacancy
+}
+
+func NewDigest(user *model.User, matches []model.SearchMatch, unsubscribeURL string) *Digest {
+	d := &Digest{
+		Name:           user.Name,
+		Frequency:      user.DigestFrequency,
+		Total:          len(matches),
+		UnsubscribeURL: unsubscribeURL,
+	}
+	if d.Name == "" {
+		d.Name = user.Email
+	}
+
+	groups := make(map[string]int)
+	for _, m := range matches {
+		i, ok := groups[m.SearchName]
+		if !ok {
+			i = len(d.Groups)
+			groups[m.SearchName] = i
+			d.Groups = append(d.Groups, DigestGroup{SearchName: m.SearchName})
+		}
+		d.Groups[i].Vacancies = append(d.Groups[i].Vacancies, m.Vacancy)
+	}
+
+	return d
+}
+
+func (d *Digest) Subject() string {
+	return fmt.Sprintf("%d new vacancies for your saved searches", d.Total)
+}
+
+const textDigest = `Hello, {{.Name}}!
+
+There are {{.Total}} new vacancies matching your saved searches.
+{{range .Groups}}
+{{.SearchName}}
+{{range .Vacancies}}
+  * {{.Title}}{{if .Company}} — {{.Company}}{{end}}
+    {{if .Salary}}{{.Salary}}
+    {{end}}{{if .Location}}{{.Location}}
+    {{end}}{{.Link}}
+{{end}}{{end}}
+You receive this {{.Frequency}} digest because you have saved searches.
+Unsubscribe: {{.UnsubscribeURL}}
+`
+
+const htmlDigest = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<p>Hello, {{.Name}}!</p>
+<p>There are {{.Total}} new vacancies matching your saved searches.</p>
+{{range .Groups}}
+<h3>{{.SearchName}}</h3>
+<ul>
+{{range .Vacancies}}
+<li>
+<a href="{{.Link}}">{{.Title}}</a>{{if .Company}} — {{.Company}}{{end}}<br>
+{{if .Salary}}<b>{{.Salary}}</b><br>{{end}}
+{{if .Location}}{{.Location}}<br>{{end}}
+{{if .HardSkills}}<small>{{range $i, $s := .HardSkills}}{{if $i}}, {{end}}{{$s}}{{end}}</small>{{end}}
+</li>
+{{end}}
+</ul>
+{{end}}
+<p style="color: #888; font-size: small;">
+You receive this {{.Frequency}} digest because you have saved searches.
+<a href="{{.UnsubscribeURL}}">Unsubscribe</a>
+</p>
+</body>
+</html>
+`
+
+var (
+	textTemplate = template.Must(template.New("digest.txt").Parse(textDigest))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Parse(htmlDigest))
+)
+
+// Render builds the message for the digest, addressed to the given email.
+func (d *Digest) Render(to string) (*Message, error) {
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, d); err != nil {
+		return nil, fmt.Errorf("cannot render digest: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, d); err != nil {
+		return nil, fmt.Errorf("cannot render digest: %w", err)
+	}
+
+	return &Message{
+		To:             to,
+		Subject:        d.Subject(),
+		Text:           text.String(),
+		HTML:           html.String(),
+		UnsubscribeURL: d.UnsubscribeURL,
+	}, nil
+}
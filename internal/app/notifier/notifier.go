@@ -0,0 +1,134 @@
+package notifier
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const UnsubscribePath = "/digest/unsubscribe"
+
+// Notifier periodically emails users a digest of their new saved search matches.
+type Notifier struct {
+	config *Config
+	store  *store.Store
+	mailer Mailer
+	secret []byte
+	now    func() time.Time
+}
+
+func New(config *Config, store *store.Store, mailer Mailer, secret []byte) *Notifier {
+	return &Notifier{
+		config: config,
+		store:  store,
+		mailer: mailer,
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+// Run sends due digests every check interval until ctx is done.
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := n.SendDue(); err != nil {
+			log.Println("cannot send digests", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends a digest to every user whose period has passed and who has
+// matches not emailed yet.
+func (n *Notifier) SendDue() error {
+	users, err := n.store.User().FindDigestRecipients()
+	if err != nil {
+		return err
+	}
+
+	now := n.now().UTC()
+	for i := range users {
+		user := &users[i]
+		if !Due(user, now) {
+			continue
+		}
+
+		sent, err := n.send(user)
+		if err != nil {
+			log.Println("cannot send digest", user.Email, err)
+			continue
+		}
+		if sent {
+			log.Println("sent digest", user.Email)
+		}
+	}
+
+	return nil
+}
+
+// Due reports whether the user's digest period has passed.
+func Due(user *model.User, now time.Time) bool {
+	if user.LastDigestAt == nil {
+		return true
+	}
+
+	period := 24 * time.Hour
+	if user.DigestFrequency == model.DigestWeekly {
+		period = 7 * 24 * time.Hour
+	}
+
+	return !now.Before(user.LastDigestAt.Add(period))
+}
+
+func (n *Notifier) send(user *model.User) (bool, error) {
+	msg, matches, err := n.Render(user)
+	if err != nil || len(matches) == 0 {
+		return false, err
+	}
+
+	if err := n.mailer.Send(msg); err != nil {
+		return false, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.ID)
+	}
+	if err := n.store.Inbox().MarkEmailed(ids); err != nil {
+		return true, err
+	}
+
+	return true, n.store.User().SetLastDigestAt(user.ID, n.now().UTC())
+}
+
+// Render builds the digest of the user's matches which were not emailed yet.
+// It is also used to preview the digest.
+func (n *Notifier) Render(user *model.User) (*Message, []model.SearchMatch, error) {
+	matches, err := n.store.Inbox().FindNotEmailed(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg, err := NewDigest(user, matches, n.UnsubscribeURL(user.ID)).Render(user.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return msg, matches, nil
+}
+
+func (n *Notifier) UnsubscribeURL(userID primitive.ObjectID) string {
+	return n.config.BaseURL + UnsubscribePath + "?token=" + url.QueryEscape(UnsubscribeToken(n.secret, userID))
+}
@@ -0,0 +1,136 @@
+package notifier
+
+import (
+	"bufio"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeSMTP accepts a single message and sends its DATA to the returned channel.
+func fakeSMTP(t *testing.T) (string, int, <-chan string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		reply("220 fake smtp")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				messages <- data.String()
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func TestSMTPMailer_SendDigest(t *testing.T) {
+	host, port, messages := fakeSMTP(t)
+
+	config := NewConfig()
+	config.SMTPHost = host
+	config.SMTPPort = port
+	config.From = "digest@example.org"
+
+	user := model.TestUser(t)
+	user.ID = primitive.NewObjectID()
+	user.DigestFrequency = model.DigestDaily
+	matches := []model.SearchMatch{
+		{SearchName: "Go remote", Vacancy: model.Vacancy{Title: "Go developer", Link: "https://hh.ru/vacancy/1", Salary: "от 300 000 ₽"}},
+		{SearchName: "Go remote", Vacancy: model.Vacancy{Title: "Senior Go <developer>", Link: "https://hh.ru/vacancy/2"}},
+	}
+
+	msg, err := NewDigest(user, matches, "http://localhost/digest/unsubscribe?token=abc").Render(user.Email)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.HTML, "Senior Go &lt;developer&gt;")
+
+	assert.NoError(t, NewSMTPMailer(config).Send(msg))
+
+	select {
+	case data := <-messages:
+		assert.Contains(t, data, "To: "+user.Email)
+		assert.Contains(t, data, "List-Unsubscribe: <http://localhost/digest/unsubscribe?token=abc>")
+		assert.Contains(t, data, "List-Unsubscribe-Post: List-Unsubscribe=One-Click")
+		assert.Contains(t, data, "multipart/alternative")
+		assert.Contains(t, data, "Content-Type: text/plain; charset=utf-8")
+		assert.Contains(t, data, "Content-Type: text/html; charset=utf-8")
+
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(data)))
+		assert.NoError(t, err)
+		assert.Contains(t, string(decoded), "https://hh.ru/vacancy/1")
+		assert.Contains(t, string(decoded), "от 300 000 ₽")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received on " + host + ":" + strconv.Itoa(port))
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	secret := []byte("secret")
+	id := primitive.NewObjectID()
+
+	token := UnsubscribeToken(secret, id)
+	parsed, err := ParseUnsubscribeToken(secret, token)
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	_, err = ParseUnsubscribeToken([]byte("other"), token)
+	assert.ErrorIs(t, err, ErrInvalidUnsubscribeToken)
+
+	_, err = ParseUnsubscribeToken(secret, primitive.NewObjectID().Hex()+token[24:])
+	assert.ErrorIs(t, err, ErrInvalidUnsubscribeToken)
+}
+
+func TestDue(t *testing.T) {
+	now := time.Now()
+	dayAgo := now.Add(-25 * time.Hour)
+
+	assert.True(t, Due(&model.User{DigestFrequency: model.DigestDaily}, now))
+	assert.True(t, Due(&model.User{DigestFrequency: model.DigestDaily, LastDigestAt: &dayAgo}, now))
+	assert.False(t, Due(&model.User{DigestFrequency: model.DigestWeekly, LastDigestAt: &dayAgo}, now))
+}
@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// UnsubscribeToken signs the user id so the link in a digest works without login.
+func UnsubscribeToken(secret []byte, userID primitive.ObjectID) string {
+	return userID.Hex() + "." + base64.RawURLEncoding.EncodeToString(unsubscribeMAC(secret, userID.Hex()))
+}
+
+// ParseUnsubscribeToken verifies the token and returns the user id it was issued for.
+func ParseUnsubscribeToken(secret []byte, token string) (primitive.ObjectID, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return primitive.NilObjectID, ErrInvalidUnsubscribeToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, unsubscribeMAC(secret, id)) {
+		return primitive.NilObjectID, ErrInvalidUnsubscribeToken
+	}
+
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidUnsubscribeToken
+	}
+
+	return userID, nil
+}
+
+func unsubscribeMAC(secret []byte, id string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("unsubscribe:" + id))
+
+	return mac.Sum(nil)
+}
@@ -1,134 +1,168 @@
 package parser
 
 import (
-	"fmt"
-	"log"
-	"net/http"
+	"errors"
+	"io"
+	"net/url"
 	"strconv"
 	"strings"
-	"time"
 	"vacancy-parser/internal/app/model"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-func GetURLS(page int, language string) []string {
-	var URLSlice []string
-	var lastPageInt int
-
-	// Send an HTTP GET request to the HH job openings page
-	resp, err := http.Get("https://hh.ru/search/vacancy?text=Javascript&from=suggest_post&area=1&hhtmFrom=main&hhtmFromLabel=vacancy_search_line&page=0")
-	if err != nil {
-		log.Fatal(err)
+// SearchURL returns the URL of a hh.ru search results page of the query. With
+// newestFirst the results are ordered by publication date.
+func SearchURL(query model.CrawlQuery, page int, newestFirst bool) string {
+	params := url.Values{}
+	params.Set("text", query.Text)
+	for _, area := range query.Areas {
+		params.Add("area", area)
 	}
-	resp.Request.URL.RawQuery = strings.Replace(resp.Request.URL.RawQuery, "korolev.", "", 1)
-	defer resp.Body.Close()
-
-	// Create a new goquery document from the HTML response
-	if page == 0 {
-		doc, err := goquery.NewDocumentFromReader(resp.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		lastPage := doc.Find("div.pager a").Slice(-2, -1).First().Text()
-		lastPageInt, err = strconv.Atoi(lastPage)
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Println("Last page:", lastPage)
-		fmt.Println("URLS is got")
-	} else {
-		lastPageInt = page
+	if query.Experience != "" {
+		params.Set("experience", query.Experience)
+	}
+	for _, schedule := range query.Schedule {
+		params.Add("schedule", schedule)
+	}
+	for _, employment := range query.Employment {
+		params.Add("employment", employment)
+	}
+	if newestFirst {
+		params.Set("order_by", "publication_time")
 	}
+	params.Set("page", strconv.Itoa(page))
 
-	for i := page; i < lastPageInt; i++ {
-		// .vacancy-serp-item_clickme - avoid this links
-		// Send an HTTP GET request to the HH job openings page
-		resp, err := http.Get("https://hh.ru/search/vacancy?text=" + language + "&from=suggest_post&area=1&hhtmFrom=main&hhtmFromLabel=vacancy_search_line&page=" + strconv.Itoa(i))
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer resp.Body.Close()
+	return "https://hh.ru/search/vacancy?" + params.Encode()
+}
 
-		// Create a new goquery document from the HTML response
-		doc, err := goquery.NewDocumentFromReader(resp.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
+// ParseListing returns the vacancy links of a search results page. An empty
+// result means the page is past the last one.
+func ParseListing(body io.Reader) ([]string, error) {
+	// Create a new goquery document from the HTML response
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, err
+	}
 
-		doc.Find("div.serp-item_link").Each(func(i int, s *goquery.Selection) {
-			if !s.HasClass("vacancy-serp-item_clickme") {
-				url := s.Find("a.bloko-link").AttrOr("href", "")
-				URLSlice = append(URLSlice, url)
+	sel := CurrentSelectors().Listing
+	var links []string
+	for _, items := range sel.Items {
+		doc.Find(items).Each(func(i int, s *goquery.Selection) {
+			// Ads link to vacancies out of the search results.
+			for _, exclude := range sel.Exclude {
+				if s.Is(exclude) {
+					return
+				}
+			}
+			for _, link := range sel.Link {
+				if link := CanonicalLink(s.Find(link).AttrOr("href", "")); link != "" {
+					links = append(links, link)
+					return
+				}
 			}
 		})
+		if len(links) > 0 {
+			break
+		}
 	}
 
-	return URLSlice
+	return links, nil
 }
 
-func GetInfoFromUrl(url string, language string) *model.Vacancy {
-	client := http.Client{
-		Timeout: 5 * time.Second,
+// CanonicalLink strips tracking parameters and regional subdomains from a
+// vacancy link, so one vacancy always has the same link. It returns "" for
+// links which are not hh.ru vacancies.
+func CanonicalLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || !strings.HasPrefix(u.Path, "/vacancy/") {
+		return ""
+	}
+	if u.Host != "hh.ru" && !strings.HasSuffix(u.Host, ".hh.ru") {
+		return ""
 	}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		if strings.Contains(err.Error(), "connectex: A connection attempt failed because the connected party did not properly respond after a period of time, or established connection failed because connected host has failed to respond") {
-			return nil
+	return "https://hh.ru" + u.Path
+}
+
+// VacancyID returns the hh.ru id of a vacancy link.
+func VacancyID(link string) string {
+	return strings.TrimPrefix(CanonicalLink(link), "https://hh.ru/vacancy/")
+}
+
+// blockMarkers are texts of hh.ru anti-bot pages, lowercased.
+var blockMarkers = []string{
+	"вы робот?",
+	"вы не робот",
+	"подтвердите, что вы не робот",
+	"account/captcha",
+	"data-qa=\"account-captcha",
+	"g-recaptcha",
+	"smartcaptcha",
+}
+
+// DetectBlock tells anti-bot and login wall pages from vacancies and listings.
+// It returns why the page at link, after redirects, is not what was asked
+// for, or "" for a regular page.
+func DetectBlock(link string, body []byte) string {
+	if u, err := url.Parse(link); err == nil {
+		switch {
+		case strings.HasPrefix(u.Path, "/account/captcha"):
+			return "captcha"
+		case strings.HasPrefix(u.Path, "/account/login"):
+			return "login wall"
 		}
-		log.Fatal(err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil
+	page := strings.ToLower(string(body))
+	for _, marker := range blockMarkers {
+		if strings.Contains(page, marker) {
+			return "captcha"
+		}
+	}
+	if strings.Contains(page, "action=\"/account/login") && !strings.Contains(page, "data-qa=\"vacancy-title") &&
+		!strings.Contains(page, "serp-item") {
+		return "login wall"
 	}
 
+	return ""
+}
+
+// ErrNoTitle is returned for vacancy pages without a title, which are not
+// vacancies or have a layout the parser does not know.
+var ErrNoTitle = errors.New("vacancy title not found")
+
+// ParseVacancy parses the vacancy page loaded from link.
+func ParseVacancy(body io.Reader, link string, language string) (*model.Vacancy, error) {
 	// Create a new goquery document from the HTML response
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	title := doc.Find("div.vacancy-title").Text()
+	sel := CurrentSelectors().Vacancy
+	title := first(doc, sel.Title)
 	if len(title) == 0 {
-		title = doc.Find("h1[data-qa='vacancy-title']").First().Text()
+		return nil, ErrNoTitle
 	}
 
-	location := doc.Find("span[data-qa='vacancy-view-raw-address']").First().Text()
-	if len(location) == 0 {
-		location = doc.Find("p[data-qa='vacancy-view-location']").First().Text()
-	}
-
-	var hardSkillSlice []string
-	doc.Find("span.bloko-tag__section_text").Each(func(i int, s *goquery.Selection) {
-		hardSkillSlice = append(hardSkillSlice, s.Text())
-	})
-
-	date := doc.Find("p.vacancy-creation-time-redesigned span").Text()
+	location := first(doc, sel.Location)
+	hardSkillSlice := all(doc, sel.HardSkills)
+	date := first(doc, sel.Date)
+	company := first(doc, sel.Company)
+	salary := first(doc, sel.Salary)
+	experience := first(doc, sel.Experience)
 
-	company := doc.Find("span.vacancy-company-name").First().Text()
-	salary := doc.Find("div[data-qa='vacancy-salary']").Text()
-
-	experience := doc.Find("span[data-qa='vacancy-experience']").Text()
-
-	fmt.Println("Title:", title)
-	fmt.Println("Location:", location)
-	fmt.Println("Hard skills:", hardSkillSlice)
-	fmt.Println("URL:", url)
-
-	return &model.Vacancy{
+	return normalize(&model.Vacancy{
 		Title:        title,
 		Location:     location,
 		HardSkills:   hardSkillSlice,
-		Link:         url,
+		Link:         link,
 		Company:      company,
 		Site:         "hh.ru",
 		Date:         date,
 		Salary:       salary,
 		Experience:   experience,
 		MainLanguage: language,
-	}
+	}), nil
 }
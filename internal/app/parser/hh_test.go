@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalLink(t *testing.T) {
+	for _, tc := range []struct {
+		link string
+		want string
+	}{
+		{"https://hh.ru/vacancy/123", "https://hh.ru/vacancy/123"},
+		{"https://spb.hh.ru/vacancy/123?query=go&hhtmFrom=vacancy_search_list", "https://hh.ru/vacancy/123"},
+		{"https://adsrv.hh.ru/click?b=1", ""},
+		{"https://example.com/vacancy/123", ""},
+		{"", ""},
+	} {
+		assert.Equal(t, tc.want, CanonicalLink(tc.link), tc.link)
+	}
+}
+
+func TestSearchURL(t *testing.T) {
+	query := model.CrawlQuery{Text: "Go", Areas: []string{"1", "2"}}
+
+	assert.Equal(t, "https://hh.ru/search/vacancy?area=1&area=2&page=3&text=Go", SearchURL(query, 3, false))
+	assert.Contains(t, SearchURL(query, 0, true), "order_by=publication_time")
+}
+
+func TestParseListing(t *testing.T) {
+	links, err := ParseListing(strings.NewReader(`<html><body>
+		<div class="serp-item_link"><a class="bloko-link" href="https://spb.hh.ru/vacancy/1?from=search">Go</a></div>
+		<div class="serp-item_link vacancy-serp-item_clickme"><a class="bloko-link" href="https://hh.ru/vacancy/2">Ad</a></div>
+		<div class="serp-item_link"><a class="bloko-link" href="https://hh.ru/vacancy/3">Python</a></div>
+	</body></html>`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/3"}, links)
+}
+
+func TestParseVacancy(t *testing.T) {
+	vacancy, err := ParseVacancy(strings.NewReader(`<html><body>
+		<h1 data-qa="vacancy-title">Go developer</h1>
+		<span class="vacancy-company-name">Acme</span>
+		<span class="bloko-tag__section_text">Go</span><span class="bloko-tag__section_text">PostgreSQL</span>
+	</body></html>`), "https://hh.ru/vacancy/1", "Go")
+	assert.NoError(t, err)
+	assert.Equal(t, "Go developer", vacancy.Title)
+	assert.Equal(t, "Acme", vacancy.Company)
+	assert.Equal(t, []string{"Go", "PostgreSQL"}, vacancy.HardSkills)
+
+	_, err = ParseVacancy(strings.NewReader(`<html><body>Captcha</body></html>`), "https://hh.ru/vacancy/1", "Go")
+	assert.ErrorIs(t, err, ErrNoTitle)
+}
+
+func TestDetectBlock(t *testing.T) {
+	for _, tc := range []struct {
+		link string
+		body string
+		want string
+	}{
+		{"https://hh.ru/vacancy/1", `<h1 data-qa="vacancy-title">Go developer</h1>`, ""},
+		{"https://hh.ru/account/captcha?backurl=%2Fvacancy%2F1", ``, "captcha"},
+		{"https://hh.ru/vacancy/1", `<div class="content"><h2>Вы робот?</h2></div>`, "captcha"},
+		{"https://hh.ru/vacancy/1", `<form action="/account/captcha"><div class="g-recaptcha"></div></form>`, "captcha"},
+		{"https://hh.ru/account/login?backurl=%2Fvacancy%2F1", ``, "login wall"},
+		{"https://hh.ru/vacancy/1", `<form action="/account/login" method="post"></form>`, "login wall"},
+		{"https://hh.ru/vacancy/1", `<form action="/account/login"></form><h1 data-qa="vacancy-title">Go</h1>`, ""},
+	} {
+		assert.Equal(t, tc.want, DetectBlock(tc.link, []byte(tc.body)), tc.link+" "+tc.body)
+	}
+}
@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"vacancy-parser/internal/app/model"
+)
+
+// APIPerPage is the number of vacancies asked for per hh.ru API listing page.
+const APIPerPage = 100
+
+// APISearchURL returns the URL of a page of the hh.ru API vacancy search.
+func APISearchURL(baseURL string, query model.CrawlQuery, page int, newestFirst bool) string {
+	params := url.Values{}
+	params.Set("text", query.Text)
+	for _, area := range query.Areas {
+		params.Add("area", area)
+	}
+	if query.Experience != "" {
+		params.Set("experience", query.Experience)
+	}
+	for _, schedule := range query.Schedule {
+		params.Add("schedule", schedule)
+	}
+	for _, employment := range query.Employment {
+		params.Add("employment", employment)
+	}
+	if newestFirst {
+		params.Set("order_by", "publication_time")
+	}
+	params.Set("page", strconv.Itoa(page))
+	params.Set("per_page", strconv.Itoa(APIPerPage))
+
+	return strings.TrimSuffix(baseURL, "/") + "/vacancies?" + params.Encode()
+}
+
+// APIVacancyURL returns the URL of a vacancy in the hh.ru API.
+func APIVacancyURL(baseURL string, link string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/vacancies/" + url.PathEscape(VacancyID(link))
+}
+
+type apiListing struct {
+	Items []struct {
+		ID           string `json:"id"`
+		AlternateURL string `json:"alternate_url"`
+	} `json:"items"`
+	Page  int `json:"page"`
+	Pages int `json:"pages"`
+}
+
+type apiSalary struct {
+	From     *int64 `json:"from"`
+	To       *int64 `json:"to"`
+	Currency string `json:"currency"`
+	Gross    *bool  `json:"gross"`
+}
+
+type apiNamed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type apiVacancy struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	AlternateURL string    `json:"alternate_url"`
+	Area         *apiNamed `json:"area"`
+	Address      *struct {
+		Raw string `json:"raw"`
+	} `json:"address"`
+	Employer    *apiNamed  `json:"employer"`
+	Salary      *apiSalary `json:"salary"`
+	Experience  *apiNamed  `json:"experience"`
+	KeySkills   []apiNamed `json:"key_skills"`
+	PublishedAt string     `json:"published_at"`
+}
+
+// ParseAPIListing returns the vacancy links of an API search page and
+// whether more pages follow.
+func ParseAPIListing(body []byte) ([]string, bool, error) {
+	var listing apiListing
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return nil, false, err
+	}
+
+	links := make([]string, 0, len(listing.Items))
+	for _, item := range listing.Items {
+		link := CanonicalLink(item.AlternateURL)
+		if link == "" && item.ID != "" {
+			link = "https://hh.ru/vacancy/" + item.ID
+		}
+		if link != "" {
+			links = append(links, link)
+		}
+	}
+
+	return links, listing.Page+1 < listing.Pages, nil
+}
+
+// ParseAPIVacancy maps an API vacancy to a vacancy stored under link, so
+// vacancies found by scraping and by the API are the same.
+func ParseAPIVacancy(body []byte, link string, language string) (*model.Vacancy, error) {
+	var v apiVacancy
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	if v.Name == "" {
+		return nil, ErrNoTitle
+	}
+
+	vacancy := &model.Vacancy{
+		Title:        v.Name,
+		Link:         link,
+		Site:         "hh.ru",
+		Date:         v.PublishedAt,
+		Salary:       formatSalary(v.Salary),
+		MainLanguage: language,
+	}
+	switch {
+	case v.Address != nil && v.Address.Raw != "":
+		vacancy.Location = v.Address.Raw
+	case v.Area != nil:
+		vacancy.Location = v.Area.Name
+	}
+	if v.Employer != nil {
+		vacancy.Company = v.Employer.Name
+	}
+	if v.Experience != nil {
+		vacancy.Experience = v.Experience.Name
+		if name, ok := experienceNames[v.Experience.ID]; ok {
+			vacancy.Experience = name
+		}
+	}
+	for _, skill := range v.KeySkills {
+		vacancy.HardSkills = append(vacancy.HardSkills, skill.Name)
+	}
+
+	return normalize(vacancy), nil
+}
+
+var (
+	currencyCodes = []string{"RUR", "USD", "EUR", "KZT", "BYR", "UAH"}
+	currencySigns = map[string]string{"RUR": "₽", "USD": "$", "EUR": "€", "KZT": "₸", "BYR": "Br", "UAH": "₴"}
+)
+
+// formatSalary writes an API salary the way the site shows it, which
+// model.ParseSalary understands.
+func formatSalary(salary *apiSalary) string {
+	if salary == nil || (salary.From == nil && salary.To == nil) {
+		return ""
+	}
+
+	var parts []string
+	if salary.From != nil {
+		parts = append(parts, "от "+groupThousands(*salary.From))
+	}
+	if salary.To != nil {
+		parts = append(parts, "до "+groupThousands(*salary.To))
+	}
+	currency, ok := currencySigns[salary.Currency]
+	if !ok {
+		currency = salary.Currency
+	}
+	if currency != "" {
+		parts = append(parts, currency)
+	}
+	if salary.Gross != nil {
+		if *salary.Gross {
+			parts = append(parts, "до вычета налогов")
+		} else {
+			parts = append(parts, "на руки")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// groupThousands formats 300000 as "300 000".
+func groupThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 && s[i-1] != '-' {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPISearchURL(t *testing.T) {
+	query := model.CrawlQuery{Text: "Go", Areas: []string{"1"}, Mode: model.QueryModeAPI}
+
+	assert.Equal(t, "https://api.hh.ru/vacancies?area=1&page=2&per_page=100&text=Go",
+		APISearchURL("https://api.hh.ru/", query, 2, false))
+	assert.Equal(t, "http://localhost/vacancies/123", APIVacancyURL("http://localhost", "https://hh.ru/vacancy/123"))
+}
+
+func TestParseAPIListing(t *testing.T) {
+	links, more, err := ParseAPIListing([]byte(`{"items": [
+		{"id": "1", "alternate_url": "https://spb.hh.ru/vacancy/1"},
+		{"id": "2"}
+	], "page": 0, "pages": 2}`))
+	assert.NoError(t, err)
+	assert.True(t, more)
+	assert.Equal(t, []string{"https://hh.ru/vacancy/1", "https://hh.ru/vacancy/2"}, links)
+
+	_, more, err = ParseAPIListing([]byte(`{"items": [], "page": 1, "pages": 2}`))
+	assert.NoError(t, err)
+	assert.False(t, more)
+}
+
+func TestParseAPIVacancy(t *testing.T) {
+	vacancy, err := ParseAPIVacancy([]byte(`{
+		"id": "1",
+		"name": "Go developer",
+		"area": {"name": "Москва"},
+		"address": null,
+		"employer": {"name": "Acme"},
+		"salary": {"from": 300000, "to": 400000, "currency": "RUR", "gross": false},
+		"experience": {"id": "between3And6", "name": "От 3 до 6 лет"},
+		"key_skills": [{"name": "Go"}, {"name": "PostgreSQL"}],
+		"published_at": "2024-05-01T10:00:00+0300"
+	}`), "https://hh.ru/vacancy/1", "Go")
+	assert.NoError(t, err)
+	assert.Equal(t, "Go developer", vacancy.Title)
+	assert.Equal(t, "Москва", vacancy.Location)
+	assert.Equal(t, "Acme", vacancy.Company)
+	assert.Equal(t, "3–6 лет", vacancy.Experience)
+	assert.Equal(t, []string{"Go", "PostgreSQL"}, vacancy.HardSkills)
+	assert.Equal(t, "2024-05-01", vacancy.Date)
+	assert.Equal(t, "https://hh.ru/vacancy/1", vacancy.Link)
+
+	from, to := model.ParseSalary(vacancy.Salary)
+	assert.Equal(t, int64(300000), from)
+	assert.Equal(t, int64(400000), to)
+
+	_, err = ParseAPIVacancy([]byte(`{"id": "1"}`), "https://hh.ru/vacancy/1", "Go")
+	assert.ErrorIs(t, err, ErrNoTitle)
+}
+
+func TestFormatSalary(t *testing.T) {
+	from, to, gross := int64(1500), int64(250000), true
+
+	for _, tc := range []struct {
+		salary *apiSalary
+		want   string
+	}{
+		{nil, ""},
+		{&apiSalary{From: &from, Currency: "USD"}, "от 1 500 $"},
+		{&apiSalary{To: &to, Currency: "RUR", Gross: &gross}, "до 250 000 ₽ до вычета налогов"},
+	} {
+		assert.Equal(t, tc.want, formatSalary(tc.salary))
+	}
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"vacancy-parser/internal/app/model"
+)
+
+// normalize puts the fields of a vacancy into the form both the site and
+// the API parsers produce, so a vacancy keeps its fingerprint whichever
+// mode crawled it.
+func normalize(v *model.Vacancy) *model.Vacancy {
+	v.Title = normalizeSpace(v.Title)
+	v.Location = normalizeSpace(v.Location)
+	v.Company = normalizeSpace(v.Company)
+	v.Experience = normalizeSpace(v.Experience)
+	for i, skill := range v.HardSkills {
+		v.HardSkills[i] = normalizeSpace(skill)
+	}
+	v.Date = normalizeDate(v.Date)
+	v.Salary = normalizeSalary(v.Salary)
+
+	return v
+}
+
+// normalizeSpace trims s and collapses its runs of whitespace, including
+// the non-breaking spaces of the site, into single spaces.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// apiTimeLayout is the layout of the timestamps of the hh.ru API.
+const apiTimeLayout = "2006-01-02T15:04:05-0700"
+
+var (
+	russianDate   = regexp.MustCompile(`(\d{1,2})\s+(\p{L}+)\s+(\d{4})`)
+	russianMonths = map[string]time.Month{
+		"января": time.January, "февраля": time.February, "марта": time.March,
+		"апреля": time.April, "мая": time.May, "июня": time.June,
+		"июля": time.July, "августа": time.August, "сентября": time.September,
+		"октября": time.October, "ноября": time.November, "декабря": time.December,
+	}
+)
+
+// normalizeDate turns an API timestamp or a site date such as
+// "15 марта 2024" into "2024-03-15". Dates it does not understand are
+// kept as they are.
+func normalizeDate(date string) string {
+	date = normalizeSpace(date)
+	if t, err := time.Parse(apiTimeLayout, date); err == nil {
+		return t.Format(time.DateOnly)
+	}
+	if m := russianDate.FindStringSubmatch(strings.ToLower(date)); m != nil {
+		if month, ok := russianMonths[m[2]]; ok {
+			day, _ := strconv.Atoi(m[1])
+			year, _ := strconv.Atoi(m[3])
+			return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+		}
+	}
+
+	return date
+}
+
+// normalizeSalary rewrites a salary the way formatSalary writes API
+// salaries. Salaries without bounds are dropped, as the API has none for
+// them, and salaries in a currency it does not know are kept as they are.
+func normalizeSalary(salary string) string {
+	salary = normalizeSpace(salary)
+	from, to := model.ParseSalary(salary)
+	if from == 0 && to == 0 {
+		return ""
+	}
+
+	s := &apiSalary{}
+	for _, code := range currencyCodes {
+		if strings.Contains(salary, currencySigns[code]) || strings.Contains(salary, code) {
+			s.Currency = code
+			break
+		}
+	}
+	if s.Currency == "" {
+		return salary
+	}
+	if from > 0 {
+		s.From = &from
+	}
+	if to > 0 {
+		s.To = &to
+	}
+	lower := strings.ToLower(salary)
+	switch {
+	case strings.Contains(lower, "до вычета"):
+		gross := true
+		s.Gross = &gross
+	case strings.Contains(lower, "на руки"):
+		gross := false
+		s.Gross = &gross
+	}
+
+	return formatSalary(s)
+}
+
+// experienceNames maps API experience IDs to the wording of the site.
+var experienceNames = map[string]string{
+	"noExperience": "не требуется",
+	"between1And3": "1–3 года",
+	"between3And6": "3–6 лет",
+	"moreThan6":    "более 6 лет",
+}
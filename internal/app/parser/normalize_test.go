@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeDate(t *testing.T) {
+	for _, tc := range []struct {
+		date string
+		want string
+	}{
+		{"2024-03-15T10:00:00+0300", "2024-03-15"},
+		{"15 марта 2024", "2024-03-15"},
+		{"Вакансия опубликована 1 мая 2024 в Москве", "2024-05-01"},
+		{"вчера", "вчера"},
+		{"", ""},
+	} {
+		assert.Equal(t, tc.want, normalizeDate(tc.date), tc.date)
+	}
+}
+
+func TestNormalizeSalary(t *testing.T) {
+	for _, tc := range []struct {
+		salary string
+		want   string
+	}{
+		{"от 300 000 до 400 000 ₽ на руки", "от 300 000 до 400 000 ₽ на руки"},
+		{"до 5 000 $ до вычета налогов", "до 5 000 $ до вычета налогов"},
+		{"от 100 000 ₽ за месяц, на руки", "от 100 000 ₽ на руки"},
+		{"от 1 000 GEL", "от 1 000 GEL"},
+		{"Уровень дохода не указан", ""},
+	} {
+		assert.Equal(t, tc.want, normalizeSalary(tc.salary), tc.salary)
+	}
+}
+
+func TestNormalize_ModesAgree(t *testing.T) {
+	site, err := ParseVacancy(strings.NewReader(`<html><body>
+		<h1 data-qa="vacancy-title">Go developer</h1>
+		<span class="vacancy-company-name">Acme</span>
+		<p data-qa="vacancy-view-location">Москва </p>
+		<p class="vacancy-creation-time-redesigned">Вакансия опубликована <span>1&nbsp;мая&nbsp;2024</span></p>
+		<div data-qa="vacancy-salary">от 300&nbsp;000 до 400&nbsp;000&nbsp;₽ на&nbsp;руки</div>
+		<span data-qa="vacancy-experience">3–6 лет</span>
+		<span class="bloko-tag__section_text">Go</span><span class="bloko-tag__section_text">PostgreSQL</span>
+	</body></html>`), "https://hh.ru/vacancy/1", "Go")
+	assert.NoError(t, err)
+
+	api, err := ParseAPIVacancy([]byte(`{
+		"id": "1",
+		"name": "Go developer",
+		"area": {"name": "Москва"},
+		"employer": {"name": "Acme"},
+		"salary": {"from": 300000, "to": 400000, "currency": "RUR", "gross": false},
+		"experience": {"id": "between3And6", "name": "От 3 до 6 лет"},
+		"key_skills": [{"name": "Go"}, {"name": "PostgreSQL"}],
+		"published_at": "2024-05-01T10:00:00+0300"
+	}`), "https://hh.ru/vacancy/1", "Go")
+	assert.NoError(t, err)
+
+	assert.Equal(t, api.Location, site.Location)
+	assert.Equal(t, api.Date, site.Date)
+	assert.Equal(t, api.Salary, site.Salary)
+	assert.Equal(t, api.Experience, site.Experience)
+	assert.Equal(t, api.ComputeFingerprint(), site.ComputeFingerprint())
+}
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// Selectors are the CSS selectors pages are parsed with. Every field lists
+// fallbacks, tried in order until one matches.
+type Selectors struct {
+	// Version names the revision of the selectors, to tell which one parsed a page.
+	Version string           `toml:"version"`
+	Listing ListingSelectors `toml:"listing"`
+	Vacancy VacancySelectors `toml:"vacancy"`
+}
+
+type ListingSelectors struct {
+	// Items hold one vacancy link each.
+	Items []string `toml:"items"`
+	// Exclude matches items which are ads rather than search results.
+	Exclude []string `toml:"exclude"`
+	// Link is the vacancy link within an item.
+	Link []string `toml:"link"`
+}
+
+type VacancySelectors struct {
+	Title      []string `toml:"title"`
+	Location   []string `toml:"location"`
+	HardSkills []string `toml:"hard_skills"`
+	Date       []string `toml:"date"`
+	Company    []string `toml:"company"`
+	Salary     []string `toml:"salary"`
+	Experience []string `toml:"experience"`
+}
+
+// DefaultSelectors fit the hh.ru markup the parser was written against.
+func DefaultSelectors() *Selectors {
+	return &Selectors{
+		Version: "builtin",
+		Listing: ListingSelectors{
+			Items:   []string{"div.serp-item_link"},
+			Exclude: []string{".vacancy-serp-item_clickme"},
+			Link:    []string{"a.bloko-link"},
+		},
+		Vacancy: VacancySelectors{
+			Title:      []string{"div.vacancy-title", "h1[data-qa='vacancy-title']"},
+			Location:   []string{"span[data-qa='vacancy-view-raw-address']", "p[data-qa='vacancy-view-location']"},
+			HardSkills: []string{"span.bloko-tag__section_text"},
+			Date:       []string{"p.vacancy-creation-time-redesigned span"},
+			Company:    []string{"span.vacancy-company-name"},
+			Salary:     []string{"div[data-qa='vacancy-salary']"},
+			Experience: []string{"span[data-qa='vacancy-experience']"},
+		},
+	}
+}
+
+// Validate checks that the selectors parse and that the fields every page
+// needs have some.
+func (s *Selectors) Validate() error {
+	required := map[string][]string{
+		"listing.items": s.Listing.Items,
+		"listing.link":  s.Listing.Link,
+		"vacancy.title": s.Vacancy.Title,
+	}
+	for name, list := range required {
+		if len(list) == 0 {
+			return fmt.Errorf("selectors %s are empty", name)
+		}
+	}
+
+	for _, list := range [][]string{
+		s.Listing.Items, s.Listing.Exclude, s.Listing.Link,
+		s.Vacancy.Title, s.Vacancy.Location, s.Vacancy.HardSkills, s.Vacancy.Date,
+		s.Vacancy.Company, s.Vacancy.Salary, s.Vacancy.Experience,
+	} {
+		for _, sel := range list {
+			if _, err := cascadia.Parse(sel); err != nil {
+				return fmt.Errorf("invalid selector %q: %w", sel, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+var selectors atomic.Pointer[Selectors]
+
+func init() {
+	selectors.Store(DefaultSelectors())
+}
+
+// CurrentSelectors returns the selectors pages are parsed with.
+func CurrentSelectors() *Selectors {
+	return selectors.Load()
+}
+
+// SetSelectors replaces the selectors for the pages parsed from now on.
+func SetSelectors(s *Selectors) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	selectors.Store(s)
+
+	return nil
+}
+
+// LoadSelectors reads selectors from a TOML file and starts using them.
+func LoadSelectors(path string) error {
+	s := &Selectors{}
+	if _, err := toml.DecodeFile(path, s); err != nil {
+		return fmt.Errorf("cannot read selectors: %w", err)
+	}
+	if err := SetSelectors(s); err != nil {
+		return fmt.Errorf("cannot use selectors from %s: %w", path, err)
+	}
+	log.Printf("using selectors %q from %s\n", s.Version, path)
+
+	return nil
+}
+
+// WatchSelectors reloads the selectors file whenever it changes until ctx is
+// done. A file which fails to load is logged and the selectors in use are kept.
+func WatchSelectors(ctx context.Context, path string, interval time.Duration) {
+	var modified time.Time
+	if info, err := os.Stat(path); err == nil {
+		modified = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Println("cannot check selectors", err)
+			continue
+		}
+		if info.ModTime().Equal(modified) {
+			continue
+		}
+		modified = info.ModTime()
+		if err := LoadSelectors(path); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// first returns the text of the first match of the first selector which
+// matches something with text.
+func first(doc *goquery.Document, fallbacks []string) string {
+	for _, sel := range fallbacks {
+		if text := doc.Find(sel).First().Text(); text != "" {
+			return text
+		}
+	}
+
+	return ""
+}
+
+// all returns the texts of every match of the first selector which matches.
+func all(doc *goquery.Document, fallbacks []string) []string {
+	for _, sel := range fallbacks {
+		var texts []string
+		doc.Find(sel).Each(func(i int, s *goquery.Selection) {
+			texts = append(texts, s.Text())
+		})
+		if len(texts) > 0 {
+			return texts
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSelectors(t *testing.T) {
+	defer SetSelectors(DefaultSelectors())
+
+	assert.NoError(t, LoadSelectors("../../../configs/selectors.toml"))
+	loaded := *CurrentSelectors()
+	loaded.Version = "builtin"
+	assert.Equal(t, *DefaultSelectors(), loaded)
+
+	invalid := DefaultSelectors()
+	invalid.Vacancy.Title = []string{"h1[data-qa="}
+	assert.Error(t, SetSelectors(invalid))
+	invalid.Vacancy.Title = nil
+	assert.Error(t, SetSelectors(invalid))
+}
+
+func TestSelectorsFallback(t *testing.T) {
+	defer SetSelectors(DefaultSelectors())
+
+	s := DefaultSelectors()
+	s.Vacancy.Title = append(s.Vacancy.Title, "h2.new-title")
+	s.Listing.Items = append(s.Listing.Items, "article.vacancy-card")
+	assert.NoError(t, SetSelectors(s))
+
+	vacancy, err := ParseVacancy(strings.NewReader(`<h2 class="new-title">Go developer</h2>`), "https://hh.ru/vacancy/1", "Go")
+	assert.NoError(t, err)
+	assert.Equal(t, "Go developer", vacancy.Title)
+
+	links, err := ParseListing(strings.NewReader(`<article class="vacancy-card"><a class="bloko-link" href="https://hh.ru/vacancy/7">Go</a></article>`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://hh.ru/vacancy/7"}, links)
+}
+
+func TestWatchSelectors(t *testing.T) {
+	defer SetSelectors(DefaultSelectors())
+
+	path := filepath.Join(t.TempDir(), "selectors.toml")
+	write := func(version string, modified time.Time) {
+		data := "version = \"" + version + "\"\n[listing]\nitems = [\"div\"]\nlink = [\"a\"]\n[vacancy]\ntitle = [\"h1\"]\n"
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+		assert.NoError(t, os.Chtimes(path, modified, modified))
+	}
+	write("v1", time.Now().Add(-time.Hour))
+	assert.NoError(t, LoadSelectors(path))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchSelectors(ctx, path, 5*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+	write("v2", time.Now())
+	assert.Eventually(t, func() bool {
+		return CurrentSelectors().Version == "v2"
+	}, time.Second, 5*time.Millisecond)
+}
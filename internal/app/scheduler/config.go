@@ -0,0 +1,25 @@
+package scheduler
+
+type Config struct {
+	Enabled bool `toml:"enabled"`
+	// Timezone schedules are evaluated in, as an IANA name. Empty means UTC.
+	Timezone string      `toml:"timezone"`
+	Jobs     []JobConfig `toml:"job"`
+}
+
+// JobConfig is a periodic crawl. Queries are names of configured crawl
+// queries, or plain search texts prefixed with "text:". Sources default to
+// every registered source.
+type JobConfig struct {
+	Name     string   `toml:"name"`
+	Schedule string   `toml:"schedule"`
+	Sources  []string `toml:"sources"`
+	Queries  []string `toml:"queries"`
+	Pages    int      `toml:"pages"`
+	// Incremental jobs stop at vacancies the previous runs already stored.
+	Incremental bool `toml:"incremental"`
+}
+
+func NewConfig() *Config {
+	return &Config{}
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Next(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 17, 30, 0, time.UTC) // Friday
+
+	testCases := []struct {
+		spec string
+		next time.Time
+	}{
+		{"0 */6 * * *", time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, time.March, 16, 3, 30, 0, 0, time.UTC)},
+		{"@nightly", time.Date(2024, time.March, 16, 3, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, time.March, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2024, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, time.March, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-7", time.Date(2024, time.March, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * */7", time.Date(2024, time.March, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0,7", time.Date(2024, time.March, 17, 9, 0, 0, 0, time.UTC)},
+		{"@every 6h", time.Date(2024, time.March, 15, 16, 17, 30, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.next, s.Next(from))
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "* * * * 8", "* * 0 * *", "*/0 * * * *", "@every 10s", "@sometimes"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.Error(t, err)
+		})
+	}
+}
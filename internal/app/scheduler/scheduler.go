@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"vacancy-parser/internal/app/crawler"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPages    = 20
+	textQueryPrefix = "text:"
+)
+
+// Scheduler starts the configured crawl jobs on their schedules. A job is
+// not started again while its previous run is still going.
+type Scheduler struct {
+	crawler  *crawler.Crawler
+	store    *store.Store
+	location *time.Location
+
+	mu      sync.Mutex
+	entries []*entry
+}
+
+type entry struct {
+	config   JobConfig
+	queries  []model.CrawlQuery
+	schedule Schedule
+	next     time.Time
+	state    model.Schedule
+}
+
+// New parses the configured schedules. Run history saved by an earlier
+// process is picked up from the store.
+func New(config *Config, crawler *crawler.Crawler, store *store.Store) (*Scheduler, error) {
+	location := time.UTC
+	if config.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(config.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid scheduler timezone: %w", err)
+		}
+	}
+
+	saved, err := store.Schedule().FindAll()
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[string]model.Schedule, len(saved))
+	for _, s := range saved {
+		history[s.Name] = s
+	}
+
+	s := &Scheduler{
+		crawler:  crawler,
+		store:    store,
+		location: location,
+	}
+	names := make(map[string]bool)
+	for _, job := range config.Jobs {
+		if job.Name == "" || names[job.Name] {
+			return nil, fmt.Errorf("scheduler job name %q is empty or duplicated", job.Name)
+		}
+		names[job.Name] = true
+		if len(job.Queries) == 0 {
+			return nil, fmt.Errorf("scheduler job %q has no queries", job.Name)
+		}
+
+		schedule, err := Parse(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("scheduler job %q: %w", job.Name, err)
+		}
+		queries := make([]model.CrawlQuery, 0, len(job.Queries))
+		for _, name := range job.Queries {
+			q, err := resolveQuery(crawler, name)
+			if err != nil {
+				return nil, fmt.Errorf("scheduler job %q: %w", job.Name, err)
+			}
+			queries = append(queries, q)
+		}
+
+		state := history[job.Name]
+		state.Name = job.Name
+		state.Spec = job.Schedule
+		// A job still running when the previous process stopped is resumed
+		// by the crawler, and adopted by Run.
+		state.Running = state.Running && state.LastJobID != nil
+		s.entries = append(s.entries, &entry{config: job, queries: queries, schedule: schedule, state: state})
+	}
+
+	return s, nil
+}
+
+// resolveQuery looks up a configured crawl query by name. A "text:" prefix
+// asks for a plain search instead.
+func resolveQuery(crawler *crawler.Crawler, name string) (model.CrawlQuery, error) {
+	if text, ok := strings.CutPrefix(name, textQueryPrefix); ok {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return model.CrawlQuery{}, fmt.Errorf("empty search text in query %q", name)
+		}
+		return model.CrawlQuery{Text: text}, nil
+	}
+
+	q, ok := crawler.Query(name)
+	if !ok {
+		return model.CrawlQuery{}, fmt.Errorf("unknown crawl query %q, use %q for a plain search", name, textQueryPrefix+name)
+	}
+
+	return q, nil
+}
+
+// Run starts jobs as they become due until ctx is done. It must be called
+// after the crawler resumed the unfinished jobs, so the ones this scheduler
+// started are still tracked.
+func (s *Scheduler) Run(ctx context.Context) {
+	now := time.Now().In(s.location)
+	s.mu.Lock()
+	for _, e := range s.entries {
+		if e.state.Running {
+			log.Println("adopted scheduled crawl", e.config.Name, e.state.LastJobID.Hex())
+			go s.watch(e, *e.state.LastJobID)
+		}
+		s.plan(e, now)
+	}
+	s.mu.Unlock()
+
+	for {
+		s.mu.Lock()
+		var wake time.Time
+		for _, e := range s.entries {
+			if !e.next.IsZero() && (wake.IsZero() || e.next.Before(wake)) {
+				wake = e.next
+			}
+		}
+		s.mu.Unlock()
+		if wake.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now().In(s.location)
+		s.mu.Lock()
+		for _, e := range s.entries {
+			if e.next.IsZero() || e.next.After(now) {
+				continue
+			}
+			s.trigger(e, now)
+			s.plan(e, now)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Schedules returns the state of every configured job, ordered by name.
+func (s *Scheduler) Schedules() []model.Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := make([]model.Schedule, 0, len(s.entries))
+	for _, e := range s.entries {
+		schedules = append(schedules, e.state)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+
+	return schedules
+}
+
+// plan computes the next activation of e. It must be called with s.mu held.
+func (s *Scheduler) plan(e *entry, now time.Time) {
+	e.next = e.schedule.Next(now)
+	e.state.NextRunAt = nil
+	if !e.next.IsZero() {
+		next := e.next.UTC()
+		e.state.NextRunAt = &next
+	}
+	s.save(e)
+}
+
+// trigger starts a crawl for e unless its previous one is still running.
+// It must be called with s.mu held.
+func (s *Scheduler) trigger(e *entry, now time.Time) {
+	if e.state.Running {
+		e.state.SkippedRuns++
+		log.Println("scheduled crawl still running, skipping", e.config.Name)
+		return
+	}
+
+	sources := e.config.Sources
+	if len(sources) == 0 {
+		sources = s.crawler.Sources()
+	}
+	pages := e.config.Pages
+	if pages == 0 {
+		pages = defaultPages
+	}
+	job := &model.CrawlJob{Sources: sources, Queries: append([]model.CrawlQuery(nil), e.queries...), Pages: pages, Incremental: e.config.Incremental}
+
+	startedAt := now.UTC()
+	e.state.LastRunAt = &startedAt
+	if err := s.crawler.Start(job); err != nil {
+		log.Println("cannot start scheduled crawl", e.config.Name, err)
+		e.state.LastJobID = nil
+		e.state.LastStatus = model.JobFailed
+		return
+	}
+
+	jobID := job.ID
+	e.state.Running = true
+	e.state.LastJobID = &jobID
+	e.state.LastStatus = job.Status
+	log.Println("started scheduled crawl", e.config.Name, jobID.Hex())
+
+	go s.watch(e, jobID)
+}
+
+// watch waits for the job e started and records how it finished.
+func (s *Scheduler) watch(e *entry, jobID primitive.ObjectID) {
+	finished, err := s.crawler.Wait(jobID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.state.Running = false
+	if err != nil {
+		log.Println("cannot find scheduled crawl job", e.config.Name, err)
+		e.state.LastStatus = model.JobFailed
+	} else {
+		e.state.LastStatus = finished.Status
+	}
+	s.save(e)
+}
+
+func (s *Scheduler) save(e *entry) {
+	state := e.state
+	if err := s.store.Schedule().Save(&state); err != nil {
+		log.Println("cannot save schedule", e.config.Name, err)
+	}
+}
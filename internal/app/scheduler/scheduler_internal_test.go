@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/crawler"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveQuery(t *testing.T) {
+	config := crawler.NewConfig()
+	config.ArchiveDir = ""
+	config.Queries = []model.CrawlQuery{{Name: "go-remote", Text: "Golang", Areas: []string{"113"}}}
+	c := crawler.New(config, nil, nil)
+
+	testCases := []struct {
+		name    string
+		query   model.CrawlQuery
+		isValid bool
+	}{
+		{"go-remote", config.Queries[0], true},
+		{"text:Java", model.CrawlQuery{Text: "Java"}, true},
+		{"text: senior Java ", model.CrawlQuery{Text: "senior Java"}, true},
+		{"Java", model.CrawlQuery{}, false},
+		{"text:", model.CrawlQuery{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := resolveQuery(c, tc.name)
+			if tc.isValid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.query, q)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const apiKeysCollection = "api_keys"
+
+var ErrQuotaExceeded = errors.New("api key quota exceeded")
+
+type APIKeyRepository struct {
+	store *Store
+}
+
+func (r *APIKeyRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(apiKeysCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "owner_id", Value: 1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create api keys indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) Create(key *model.APIKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+	key.WindowStart = key.CreatedAt
+
+	result, err := r.store.db.Collection(apiKeysCollection).InsertOne(context.Background(), key)
+	if err != nil {
+		return fmt.Errorf("cannot create api key: %w", err)
+	}
+
+	key.ID = result.InsertedID.(primitive.ObjectID)
+
+	return nil
+}
+
+// FindActiveByHash returns the not revoked key with the given hash.
+func (r *APIKeyRepository) FindActiveByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.store.db.Collection(apiKeysCollection).FindOne(context.Background(), bson.D{
+		{Key: "hash", Value: hash},
+		{Key: "revoked_at", Value: nil},
+	}).Decode(&key)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) FindByOwner(ownerID primitive.ObjectID) ([]model.APIKey, error) {
+	keys := []model.APIKey{}
+	cursor, err := r.store.db.Collection(apiKeysCollection).Find(context.Background(), bson.D{{Key: "owner_id", Value: ownerID}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find api keys: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &keys); err != nil {
+		return nil, fmt.Errorf("cannot decode api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke marks the key revoked. When ownerID is not nil the key must belong to that owner.
+func (r *APIKeyRepository) Revoke(id primitive.ObjectID, ownerID *primitive.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "revoked_at", Value: nil}}
+	if ownerID != nil {
+		filter = append(filter, bson.E{Key: "owner_id", Value: *ownerID})
+	}
+
+	result, err := r.store.db.Collection(apiKeysCollection).UpdateOne(context.Background(), filter,
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: time.Now().UTC()}}}})
+	if err != nil {
+		return fmt.Errorf("cannot revoke api key: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RevokeByOwner revokes every active key of the owner, or of every owner
+// when ownerID is nil.
+func (r *APIKeyRepository) RevokeByOwner(ownerID *primitive.ObjectID) error {
+	filter := bson.D{{Key: "revoked_at", Value: nil}}
+	if ownerID != nil {
+		filter = append(filter, bson.E{Key: "owner_id", Value: *ownerID})
+	}
+
+	_, err := r.store.db.Collection(apiKeysCollection).UpdateMany(context.Background(), filter,
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: time.Now().UTC()}}}})
+	if err != nil {
+		return fmt.Errorf("cannot revoke api keys: %w", err)
+	}
+
+	return nil
+}
+
+// Consume counts a request against the key quota and records its last use.
+// The quota window is fixed: it starts with the first request after the
+// previous window has passed. ErrQuotaExceeded is returned once the key has
+// made key.Quota requests within the current window.
+func (r *APIKeyRepository) Consume(key *model.APIKey, window time.Duration) error {
+	coll := r.store.db.Collection(apiKeysCollection)
+	now := time.Now().UTC()
+
+	inWindow := bson.D{
+		{Key: "_id", Value: key.ID},
+		{Key: "window_start", Value: bson.D{{Key: "$gt", Value: now.Add(-window)}}},
+	}
+	if key.Quota > 0 {
+		inWindow = append(inWindow, bson.E{Key: "window_count", Value: bson.D{{Key: "$lt", Value: key.Quota}}})
+	}
+	use := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "window_count", Value: 1}, {Key: "request_count", Value: 1}}},
+		{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: now}}},
+	}
+	result, err := coll.UpdateOne(context.Background(), inWindow, use)
+	if err != nil {
+		return fmt.Errorf("cannot update api key usage: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	newWindow := bson.D{
+		{Key: "_id", Value: key.ID},
+		{Key: "window_start", Value: bson.D{{Key: "$lte", Value: now.Add(-window)}}},
+	}
+	result, err = coll.UpdateOne(context.Background(), newWindow, bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "request_count", Value: 1}}},
+		{Key: "$set", Value: bson.D{
+			{Key: "window_start", Value: now},
+			{Key: "window_count", Value: 1},
+			{Key: "last_used_at", Value: now},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot update api key usage: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// A concurrent request may have opened the new window in between.
+	result, err = coll.UpdateOne(context.Background(), inWindow, use)
+	if err != nil {
+		return fmt.Errorf("cannot update api key usage: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
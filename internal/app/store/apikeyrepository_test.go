@@ -0,0 +1,31 @@
+package store_test
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyRepository_RevokedWithOwner(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("users", "api_keys")
+
+	user := model.TestUser(t)
+	_, err := s.User().CreateUser(user)
+	require.NoError(t, err)
+	key := &model.APIKey{OwnerID: user.ID, Name: "ci", Hash: "hash", Scope: model.APIKeyScopeReadWrite}
+	require.NoError(t, s.APIKey().Create(key))
+
+	_, err = s.APIKey().FindActiveByHash("hash")
+	require.NoError(t, err)
+
+	count, err := s.User().DeleteUserByEmail(user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = s.APIKey().FindActiveByHash("hash")
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const applicationsCollection = "applications"
+
+var ErrAlreadyTracked = errors.New("vacancy is already tracked")
+
+type ApplicationRepository struct {
+	store *Store
+}
+
+func (r *ApplicationRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(applicationsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "vacancy_link", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create applications indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ApplicationRepository) Create(app *model.Application) error {
+	if err := app.Validate(); err != nil {
+		return err
+	}
+	app.CreatedAt = time.Now().UTC()
+	app.UpdatedAt = app.CreatedAt
+
+	result, err := r.store.db.Collection(applicationsCollection).InsertOne(context.Background(), app)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyTracked
+		}
+		return fmt.Errorf("cannot create application: %w", err)
+	}
+
+	app.ID = result.InsertedID.(primitive.ObjectID)
+
+	return nil
+}
+
+func (r *ApplicationRepository) Find(id, userID primitive.ObjectID) (*model.Application, error) {
+	var app model.Application
+	err := r.store.db.Collection(applicationsCollection).FindOne(context.Background(), bson.D{
+		{Key: "_id", Value: id},
+		{Key: "user_id", Value: userID},
+	}).Decode(&app)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find application: %w", err)
+	}
+
+	return &app, nil
+}
+
+// FindByUser lists the user's applications, optionally limited to a stage,
+// most recently updated first.
+func (r *ApplicationRepository) FindByUser(userID primitive.ObjectID, stage string) ([]model.Application, error) {
+	filter := bson.D{{Key: "user_id", Value: userID}}
+	if stage != "" {
+		filter = append(filter, bson.E{Key: "stage", Value: stage})
+	}
+
+	apps := []model.Application{}
+	cursor, err := r.store.db.Collection(applicationsCollection).Find(context.Background(), filter, options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find applications: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &apps); err != nil {
+		return nil, fmt.Errorf("cannot decode applications: %w", err)
+	}
+
+	return apps, nil
+}
+
+func (r *ApplicationRepository) Update(app *model.Application) error {
+	if err := app.Validate(); err != nil {
+		return err
+	}
+	app.UpdatedAt = time.Now().UTC()
+
+	result, err := r.store.db.Collection(applicationsCollection).UpdateOne(context.Background(),
+		bson.D{{Key: "_id", Value: app.ID}, {Key: "user_id", Value: app.UserID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "stage", Value: app.Stage},
+			{Key: "notes", Value: app.Notes},
+			{Key: "history", Value: app.History},
+			{Key: "updated_at", Value: app.UpdatedAt},
+		}}})
+	if err != nil {
+		return fmt.Errorf("cannot update application: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *ApplicationRepository) Delete(id, userID primitive.ObjectID) error {
+	result, err := r.store.db.Collection(applicationsCollection).DeleteOne(context.Background(), bson.D{
+		{Key: "_id", Value: id},
+		{Key: "user_id", Value: userID},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delete application: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
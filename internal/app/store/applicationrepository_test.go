@@ -0,0 +1,79 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplicationRepository_FindUpdate(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("applications")
+
+	userID, otherID := primitive.NewObjectID(), primitive.NewObjectID()
+	app := &model.Application{UserID: userID, VacancyLink: "https://hh.ru/vacancy/1", Vacancy: model.Vacancy{Title: "Go developer"}}
+	require.NoError(t, app.MoveTo(model.StageSaved, time.Time{}, ""))
+	require.NoError(t, s.Application().Create(app))
+
+	again := *app
+	again.ID = primitive.NilObjectID
+	assert.ErrorIs(t, s.Application().Create(&again), store.ErrAlreadyTracked)
+
+	_, err := s.Application().Find(app.ID, otherID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	require.NoError(t, app.MoveTo(model.StageApplied, time.Time{}, "sent CV"))
+	app.Notes = "referral"
+	require.NoError(t, s.Application().Update(app))
+
+	stolen := *app
+	stolen.UserID = otherID
+	stolen.Notes = "mine now"
+	assert.ErrorIs(t, s.Application().Update(&stolen), store.ErrRecordNotFound)
+
+	found, err := s.Application().Find(app.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StageApplied, found.Stage)
+	assert.Equal(t, "referral", found.Notes)
+	require.Len(t, found.History, 2)
+	assert.Equal(t, "sent CV", found.History[1].Note)
+
+	applied, err := s.Application().FindByUser(userID, model.StageApplied)
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+	others, err := s.Application().FindByUser(otherID, "")
+	require.NoError(t, err)
+	assert.Empty(t, others)
+}
+
+func TestApplicationRepository_OutlivesVacancy(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("applications", "vacancies")
+
+	link := "https://hh.ru/vacancy/1"
+	_, err := s.Vacancy().UpsertVacancies([]*model.Vacancy{{Title: "Go developer", Link: link, Company: "Acme"}})
+	require.NoError(t, err)
+	vacancy, err := s.Vacancy().FindVacancyByLink(link)
+	require.NoError(t, err)
+
+	userID := primitive.NewObjectID()
+	app := &model.Application{UserID: userID, VacancyLink: link, Vacancy: *vacancy, Stage: model.StageApplied}
+	require.NoError(t, s.Application().Create(app))
+
+	// The vacancy is re-crawled with another title, then closes.
+	_, err = s.Vacancy().UpsertVacancies([]*model.Vacancy{{Title: "Senior Go developer", Link: link, Company: "Acme"}})
+	require.NoError(t, err)
+	_, err = s.Vacancy().Close(link)
+	require.NoError(t, err)
+
+	found, err := s.Application().Find(app.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "Go developer", found.Vacancy.Title)
+	assert.Equal(t, "Acme", found.Vacancy.Company)
+	assert.False(t, found.Vacancy.Closed)
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"sync"
+	"time"
+	"vacancy-parser/internal/app/model"
+)
+
+// BatchStats holds counters accumulated by a BatchWriter.
+type BatchStats struct {
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+	Failed   int64 `json:"failed"`
+}
+
+// BatchWriter buffers vacancies sent to its input channel and writes them
+// with UpsertVacancies every size items or every interval, whichever comes first.
+type BatchWriter struct {
+	write    func([]*model.Vacancy) (*BulkResult, error)
+	size     int
+	interval time.Duration
+	onError  func(BulkItemError)
+	onFlush  func(*BulkResult)
+
+	input chan *model.Vacancy
+	done  chan struct{}
+	once  sync.Once
+
+	mu      sync.Mutex
+	stats   BatchStats
+	created []*model.Vacancy
+	changed []*model.Vacancy
+}
+
+// NewBatchWriter starts a writer goroutine sized by the store config. onError is
+// called from that goroutine for every vacancy which could not be written, it may be nil.
+func (r *VacancyRepository) NewBatchWriter(onError func(BulkItemError)) *BatchWriter {
+	size := r.store.config.BatchSize
+	interval := time.Duration(r.store.config.BatchFlushInterval) * time.Millisecond
+	if size <= 0 {
+		size = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return newBatchWriter(r.UpsertVacancies, size, interval, onError)
+}
+
+func newBatchWriter(write func([]*model.Vacancy) (*BulkResult, error), size int, interval time.Duration,
+	onError func(BulkItemError)) *BatchWriter {
+	w := &BatchWriter{
+		write:    write,
+		size:     size,
+		interval: interval,
+		onError:  onError,
+		input:    make(chan *model.Vacancy, size),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+
+	return w
+}
+
+// Input returns the channel vacancies are fed through. It must not be used after Close.
+func (w *BatchWriter) Input() chan<- *model.Vacancy {
+	return w.input
+}
+
+// OnFlush sets a function called from the writer goroutine with the result of
+// every batch written. It must be called before the first vacancy is sent.
+func (w *BatchWriter) OnFlush(f func(*BulkResult)) {
+	w.onFlush = f
+}
+
+// Close flushes the buffered vacancies, stops the writer and returns its counters.
+func (w *BatchWriter) Close() BatchStats {
+	w.once.Do(func() {
+		close(w.input)
+	})
+	<-w.done
+
+	return w.Stats()
+}
+
+// Stats returns the counters accumulated so far.
+func (w *BatchWriter) Stats() BatchStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.stats
+}
+
+// Created returns the vacancies which were not stored before this writer wrote them.
+func (w *BatchWriter) Created() []*model.Vacancy {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]*model.Vacancy(nil), w.created...)
+}
+
+// Changed returns the stored vacancies this writer changed or reopened.
+func (w *BatchWriter) Changed() []*model.Vacancy {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]*model.Vacancy(nil), w.changed...)
+}
+
+func (w *BatchWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	buf := make([]*model.Vacancy, 0, w.size)
+	for {
+		select {
+		case v, ok := <-w.input:
+			if !ok {
+				w.flush(buf)
+				return
+			}
+			buf = append(buf, v)
+			if len(buf) >= w.size {
+				w.flush(buf)
+				buf = make([]*model.Vacancy, 0, w.size)
+			}
+		case <-ticker.C:
+			if len(buf) > 0 {
+				w.flush(buf)
+				buf = make([]*model.Vacancy, 0, w.size)
+			}
+		}
+	}
+}
+
+func (w *BatchWriter) flush(buf []*model.Vacancy) {
+	if len(buf) == 0 {
+		return
+	}
+
+	res, err := w.write(buf)
+	if err != nil {
+		res = &BulkResult{}
+		for _, v := range buf {
+			res.Errors = append(res.Errors, BulkItemError{Vacancy: v, Err: err})
+		}
+	}
+
+	w.mu.Lock()
+	w.stats.Inserted += res.Inserted
+	w.stats.Updated += res.Updated
+	w.stats.Failed += int64(len(res.Errors))
+	w.created = append(w.created, res.Created...)
+	w.changed = append(w.changed, res.Changed...)
+	w.mu.Unlock()
+
+	if w.onError != nil {
+		for _, e := range res.Errors {
+			w.onError(e)
+		}
+	}
+	if w.onFlush != nil {
+		w.onFlush(res)
+	}
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder stands in for UpsertVacancies and keeps the batches it was given.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]*model.Vacancy
+	flushed chan int
+	err     error
+}
+
+func newRecorder() *recorder {
+	return &recorder{flushed: make(chan int, 16)}
+}
+
+func (rec *recorder) write(batch []*model.Vacancy) (*BulkResult, error) {
+	rec.mu.Lock()
+	rec.batches = append(rec.batches, batch)
+	rec.mu.Unlock()
+	rec.flushed <- len(batch)
+
+	if rec.err != nil {
+		return nil, rec.err
+	}
+
+	return &BulkResult{Inserted: int64(len(batch)), Written: batch, Created: batch}, nil
+}
+
+func (rec *recorder) sizes() []int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	sizes := make([]int, 0, len(rec.batches))
+	for _, b := range rec.batches {
+		sizes = append(sizes, len(b))
+	}
+
+	return sizes
+}
+
+func vacancies(n int) []*model.Vacancy {
+	list := make([]*model.Vacancy, n)
+	for i := range list {
+		list[i] = &model.Vacancy{Title: "Go developer", Link: "https://hh.ru/vacancy/" + strconv.Itoa(i)}
+	}
+
+	return list
+}
+
+func TestBatchWriter_FlushBySize(t *testing.T) {
+	rec := newRecorder()
+	w := newBatchWriter(rec.write, 2, time.Hour, nil)
+
+	for _, v := range vacancies(5) {
+		w.Input() <- v
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case n := <-rec.flushed:
+			assert.Equal(t, 2, n)
+		case <-time.After(time.Second):
+			t.Fatal("full batch was not flushed")
+		}
+	}
+
+	stats := w.Close()
+	assert.Equal(t, []int{2, 2, 1}, rec.sizes())
+	assert.Equal(t, BatchStats{Inserted: 5}, stats)
+	assert.Len(t, w.Created(), 5)
+}
+
+func TestBatchWriter_FlushByInterval(t *testing.T) {
+	rec := newRecorder()
+	w := newBatchWriter(rec.write, 100, 20*time.Millisecond, nil)
+	defer w.Close()
+
+	for _, v := range vacancies(3) {
+		w.Input() <- v
+	}
+	select {
+	case n := <-rec.flushed:
+		assert.Equal(t, 3, n)
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not flushed on the interval")
+	}
+	assert.Equal(t, int64(3), w.Stats().Inserted)
+}
+
+func TestBatchWriter_CloseFlushesPartialBatch(t *testing.T) {
+	rec := newRecorder()
+	w := newBatchWriter(rec.write, 100, time.Hour, nil)
+
+	var flushes []*BulkResult
+	w.OnFlush(func(res *BulkResult) {
+		flushes = append(flushes, res)
+	})
+	for _, v := range vacancies(3) {
+		w.Input() <- v
+	}
+
+	stats := w.Close()
+	assert.Equal(t, []int{3}, rec.sizes())
+	assert.Equal(t, int64(3), stats.Inserted)
+	assert.Len(t, flushes, 1)
+
+	// Closing again returns the same counters without writing anything.
+	assert.Equal(t, stats, w.Close())
+	assert.Equal(t, []int{3}, rec.sizes())
+}
+
+func TestBatchWriter_Errors(t *testing.T) {
+	rec := newRecorder()
+	rec.err = errors.New("connection refused")
+
+	var failed []BulkItemError
+	w := newBatchWriter(rec.write, 100, time.Hour, func(e BulkItemError) {
+		failed = append(failed, e)
+	})
+	for _, v := range vacancies(2) {
+		w.Input() <- v
+	}
+
+	stats := w.Close()
+	assert.Equal(t, BatchStats{Failed: 2}, stats)
+	assert.Len(t, failed, 2)
+	assert.ErrorIs(t, failed[0].Err, rec.err)
+}
@@ -2,8 +2,16 @@ package store
 
 type Config struct {
 	DatabaseURL string `toml:"database_url"`
+	// Database is the name of the database within DatabaseURL.
+	Database           string `toml:"database"`
+	BatchSize          int    `toml:"batch_size"`
+	BatchFlushInterval int    `toml:"batch_flush_interval_ms"`
 }
 
 func NewConfig() *Config {
-	return &Config{}
+	return &Config{
+		Database:           "vacancy_parser",
+		BatchSize:          100,
+		BatchFlushInterval: 500,
+	}
 }
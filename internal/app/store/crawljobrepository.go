@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const crawlJobsCollection = "crawl_jobs"
+
+type CrawlJobRepository struct {
+	store *Store
+}
+
+func (r *CrawlJobRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(crawlJobsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create crawl jobs indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CrawlJobRepository) Create(job *model.CrawlJob) error {
+	if err := job.Validate(); err != nil {
+		return err
+	}
+	job.Status = model.JobQueued
+	job.CreatedAt = time.Now().UTC()
+
+	result, err := r.store.db.Collection(crawlJobsCollection).InsertOne(context.Background(), job)
+	if err != nil {
+		return fmt.Errorf("cannot create crawl job: %w", err)
+	}
+
+	job.ID = result.InsertedID.(primitive.ObjectID)
+
+	return nil
+}
+
+func (r *CrawlJobRepository) Find(id primitive.ObjectID) (*model.CrawlJob, error) {
+	var job model.CrawlJob
+	err := r.store.db.Collection(crawlJobsCollection).FindOne(context.Background(), bson.D{{Key: "_id", Value: id}}).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find crawl job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// FindRecent returns the latest jobs, newest first.
+func (r *CrawlJobRepository) FindRecent(limit int64) ([]model.CrawlJob, error) {
+	jobs := []model.CrawlJob{}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.store.db.Collection(crawlJobsCollection).Find(context.Background(), bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find crawl jobs: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &jobs); err != nil {
+		return nil, fmt.Errorf("cannot decode crawl jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Update saves the state of the job, everything but what it was created with.
+func (r *CrawlJobRepository) Update(job *model.CrawlJob) error {
+	_, err := r.store.db.Collection(crawlJobsCollection).UpdateOne(context.Background(),
+		bson.D{{Key: "_id", Value: job.ID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: job.Status},
+			{Key: "counters", Value: job.Counters},
+			{Key: "selectors", Value: job.Selectors},
+			{Key: "quality", Value: job.Quality},
+			{Key: "mode_quality", Value: job.ModeQuality},
+			{Key: "degraded", Value: job.Degraded},
+			{Key: "progress", Value: job.Progress},
+			{Key: "error", Value: job.Error},
+			{Key: "blocked_until", Value: job.BlockedUntil},
+			{Key: "started_at", Value: job.StartedAt},
+			{Key: "finished_at", Value: job.FinishedAt},
+		}}})
+	if err != nil {
+		return fmt.Errorf("cannot update crawl job: %w", err)
+	}
+
+	return nil
+}
+
+// FindBaseline returns the parse quality in the query mode of the latest
+// succeeded jobs which were not degraded, to compare new jobs with.
+func (r *CrawlJobRepository) FindBaseline(mode string, limit int64) ([]model.ParseQuality, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "finished_at", Value: -1}}).
+		SetLimit(limit).
+		SetProjection(bson.D{{Key: "mode_quality", Value: 1}})
+	cursor, err := r.store.db.Collection(crawlJobsCollection).Find(context.Background(), bson.D{
+		{Key: "status", Value: model.JobSucceeded},
+		{Key: "mode_quality", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+			{Key: "mode", Value: mode},
+			{Key: "parsed", Value: bson.D{{Key: "$gt", Value: 0}}},
+		}}}},
+		{Key: "degraded", Value: bson.D{{Key: "$in", Value: bson.A{nil, bson.A{}}}}},
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find crawl jobs: %w", err)
+	}
+
+	var jobs []model.CrawlJob
+	if err := cursor.All(context.Background(), &jobs); err != nil {
+		return nil, fmt.Errorf("cannot decode crawl jobs: %w", err)
+	}
+
+	baseline := make([]model.ParseQuality, 0, len(jobs))
+	for _, job := range jobs {
+		baseline = append(baseline, *job.QualityOf(mode))
+	}
+
+	return baseline, nil
+}
+
+// FindUnfinished returns the jobs left queued or running by a previous
+// process, oldest first.
+func (r *CrawlJobRepository) FindUnfinished() ([]model.CrawlJob, error) {
+	jobs := []model.CrawlJob{}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.store.db.Collection(crawlJobsCollection).Find(context.Background(),
+		bson.D{{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{model.JobQueued, model.JobRunning, model.JobBlocked}}}}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find unfinished crawl jobs: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &jobs); err != nil {
+		return nil, fmt.Errorf("cannot decode crawl jobs: %w", err)
+	}
+
+	return jobs, nil
+}
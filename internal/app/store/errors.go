@@ -0,0 +1,9 @@
+package store
+
+import "errors"
+
+var (
+	ErrRecordNotFound = errors.New("record not found")
+	ErrEmailTaken     = errors.New("email is already taken")
+	ErrTokenReused    = errors.New("token has already been used")
+)
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"regexp"
+	"strings"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// vacancyFilter translates the filters into a query for the open vacancies
+// which Filters.Match accepts.
+func vacancyFilter(f model.Filters) bson.D {
+	filter := bson.D{openVacancy}
+
+	var and bson.A
+	if f.MainLanguage != "" {
+		and = append(and, bson.D{{Key: "mainlanguage", Value: equalFold(f.MainLanguage)}})
+	}
+	if f.Tag != "" {
+		and = append(and, bson.D{{Key: "tags", Value: equalFold(strings.TrimSpace(f.Tag))}})
+	}
+	if f.Location != "" {
+		and = append(and, bson.D{{Key: "location", Value: containsFold(f.Location)}})
+	}
+	for _, skill := range f.HardSkills {
+		and = append(and, bson.D{{Key: "hardskills", Value: equalFold(strings.TrimSpace(skill))}})
+	}
+	for _, word := range strings.Fields(f.Text) {
+		var or bson.A
+		for _, field := range []string{"title", "company", "mainlanguage", "hardskills"} {
+			or = append(or, bson.D{{Key: field, Value: containsFold(word)}})
+		}
+		and = append(and, bson.D{{Key: "$or", Value: or}})
+	}
+	if f.Remote {
+		var or bson.A
+		for _, marker := range model.RemoteMarkers {
+			or = append(or, bson.D{{Key: "title", Value: containsFold(marker)}}, bson.D{{Key: "location", Value: containsFold(marker)}})
+		}
+		and = append(and, bson.D{{Key: "$or", Value: or}})
+	}
+	if f.SalaryFrom > 0 {
+		and = append(and, bson.D{{Key: "salary_max", Value: bson.D{{Key: "$gte", Value: f.SalaryFrom}}}})
+	}
+
+	if len(and) > 0 {
+		filter = append(filter, bson.E{Key: "$and", Value: and})
+	}
+
+	return filter
+}
+
+// equalFold matches the whole value, ignoring case and surrounding spaces.
+func equalFold(s string) primitive.Regex {
+	return primitive.Regex{Pattern: `^\s*` + regexp.QuoteMeta(s) + `\s*$`, Options: "i"}
+}
+
+func containsFold(s string) primitive.Regex {
+	return primitive.Regex{Pattern: regexp.QuoteMeta(strings.TrimSpace(s)), Options: "i"}
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"regexp"
+	"testing"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVacancyFilter(t *testing.T) {
+	assert.Equal(t, bson.D{openVacancy}, vacancyFilter(model.Filters{}))
+
+	filter := vacancyFilter(model.Filters{Text: "c++ senior", HardSkills: []string{" Go "}, SalaryFrom: 300000})
+	assert.Len(t, filter, 2)
+	and := filter[1].Value.(bson.A)
+	assert.Equal(t, bson.D{{Key: "hardskills", Value: primitive.Regex{Pattern: `^\s*Go\s*$`, Options: "i"}}}, and[0])
+	assert.Len(t, and[1].(bson.D)[0].Value, 4)
+	assert.Equal(t, bson.D{{Key: "salary_max", Value: bson.D{{Key: "$gte", Value: int64(300000)}}}}, and[3])
+}
+
+func TestFoldPatterns(t *testing.T) {
+	// The patterns are PCRE in the store, these ones read the same in Go.
+	for _, tc := range []struct {
+		re    primitive.Regex
+		value string
+		match bool
+	}{
+		{equalFold("Go"), "go", true},
+		{equalFold("Go"), " Go ", true},
+		{equalFold("Go"), "Golang", false},
+		{containsFold("c++"), "Senior C++ developer", true},
+		{containsFold("c++"), "Senior C developer", false},
+		{containsFold("Москва"), "г. москва, Арбат", true},
+	} {
+		re := regexp.MustCompile("(?" + tc.re.Options + ")" + tc.re.Pattern)
+		assert.Equal(t, tc.match, re.MatchString(tc.value), tc.re.Pattern+" "+tc.value)
+	}
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const frontierCollection = "crawl_frontier"
+
+// FrontierRepository keeps the links crawl jobs have listed until the jobs
+// finish, so an interrupted job does not lose them.
+type FrontierRepository struct {
+	store *Store
+}
+
+func (r *FrontierRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(frontierCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "job_id", Value: 1}, {Key: "source", Value: 1}, {Key: "query", Value: 1}, {Key: "link", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "job_id", Value: 1}, {Key: "state", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create crawl frontier indexes: %w", err)
+	}
+
+	return nil
+}
+
+// Add stores the links as pending and returns the items of the links the job
+// had not listed yet for the source and query.
+func (r *FrontierRepository) Add(jobID primitive.ObjectID, source, query string, links []string) ([]model.FrontierItem, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	models := make([]mongo.WriteModel, 0, len(links))
+	for _, link := range links {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "job_id", Value: jobID}, {Key: "source", Value: source}, {Key: "query", Value: query}, {Key: "link", Value: link}}).
+			SetUpdate(bson.D{{Key: "$setOnInsert", Value: bson.D{
+				{Key: "state", Value: model.FrontierPending},
+				{Key: "attempts", Value: 0},
+				{Key: "updated_at", Value: now},
+			}}}).
+			SetUpsert(true))
+	}
+
+	result, err := r.store.db.Collection(frontierCollection).BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return nil, fmt.Errorf("cannot add links to crawl frontier: %w", err)
+	}
+
+	items := make([]model.FrontierItem, 0, len(result.UpsertedIDs))
+	for i, link := range links {
+		id, ok := result.UpsertedIDs[int64(i)]
+		if !ok {
+			continue
+		}
+		items = append(items, model.FrontierItem{
+			ID:        id.(primitive.ObjectID),
+			JobID:     jobID,
+			Source:    source,
+			Query:     query,
+			Link:      link,
+			State:     model.FrontierPending,
+			UpdatedAt: now,
+		})
+	}
+
+	return items, nil
+}
+
+// FindPending returns the links of the source and query the job still has to fetch.
+func (r *FrontierRepository) FindPending(jobID primitive.ObjectID, source, query string) ([]model.FrontierItem, error) {
+	items := []model.FrontierItem{}
+	cursor, err := r.store.db.Collection(frontierCollection).Find(context.Background(), bson.D{
+		{Key: "job_id", Value: jobID},
+		{Key: "source", Value: source},
+		{Key: "query", Value: query},
+		{Key: "state", Value: model.FrontierPending},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find pending links: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &items); err != nil {
+		return nil, fmt.Errorf("cannot decode pending links: %w", err)
+	}
+
+	return items, nil
+}
+
+// Start marks the item in flight and counts the attempt.
+func (r *FrontierRepository) Start(id primitive.ObjectID) error {
+	_, err := r.store.db.Collection(frontierCollection).UpdateOne(context.Background(),
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{
+			{Key: "$set", Value: bson.D{{Key: "state", Value: model.FrontierInFlight}, {Key: "updated_at", Value: time.Now().UTC()}}},
+			{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+		})
+	if err != nil {
+		return fmt.Errorf("cannot update crawl frontier: %w", err)
+	}
+
+	return nil
+}
+
+// Finish marks the item done, or failed when fetchErr is not nil.
+func (r *FrontierRepository) Finish(id primitive.ObjectID, fetchErr error) error {
+	set := bson.D{{Key: "state", Value: model.FrontierDone}, {Key: "updated_at", Value: time.Now().UTC()}}
+	if fetchErr != nil {
+		set[0].Value = model.FrontierFailed
+		set = append(set, bson.E{Key: "error", Value: fetchErr.Error()})
+	}
+
+	_, err := r.store.db.Collection(frontierCollection).UpdateOne(context.Background(),
+		bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		return fmt.Errorf("cannot update crawl frontier: %w", err)
+	}
+
+	return nil
+}
+
+// Requeue makes the links a job had in flight when it was interrupted, and
+// the failed ones which have attempts left, pending again.
+func (r *FrontierRepository) Requeue(jobID primitive.ObjectID) (int64, error) {
+	result, err := r.store.db.Collection(frontierCollection).UpdateMany(context.Background(),
+		bson.D{
+			{Key: "job_id", Value: jobID},
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "state", Value: model.FrontierInFlight}},
+				bson.D{{Key: "state", Value: model.FrontierFailed}, {Key: "attempts", Value: bson.D{{Key: "$lt", Value: model.MaxFetchAttempts}}}},
+			}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "state", Value: model.FrontierPending}}}})
+	if err != nil {
+		return 0, fmt.Errorf("cannot requeue crawl frontier: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// DeleteByJob drops the frontier of a finished job.
+func (r *FrontierRepository) DeleteByJob(jobID primitive.ObjectID) error {
+	_, err := r.store.db.Collection(frontierCollection).DeleteMany(context.Background(), bson.D{{Key: "job_id", Value: jobID}})
+	if err != nil {
+		return fmt.Errorf("cannot delete crawl frontier: %w", err)
+	}
+
+	return nil
+}
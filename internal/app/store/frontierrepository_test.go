@@ -0,0 +1,103 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func links(items []model.FrontierItem) []string {
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		list = append(list, item.Link)
+	}
+
+	return list
+}
+
+func TestFrontierRepository_Add(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("crawl_frontier")
+
+	jobID := primitive.NewObjectID()
+	items, err := s.Frontier().Add(jobID, "hh.ru", "go", []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, links(items))
+	assert.Equal(t, model.FrontierPending, items[0].State)
+
+	// Links listed before are not returned again.
+	items, err = s.Frontier().Add(jobID, "hh.ru", "go", []string{"b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, links(items))
+
+	// Other queries and jobs have frontiers of their own.
+	items, err = s.Frontier().Add(jobID, "hh.ru", "python", []string{"b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, links(items))
+	items, err = s.Frontier().Add(primitive.NewObjectID(), "hh.ru", "go", []string{"b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, links(items))
+
+	pending, err := s.Frontier().FindPending(jobID, "hh.ru", "go")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, links(pending))
+}
+
+func TestFrontierRepository_StartFinish(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("crawl_frontier")
+
+	jobID := primitive.NewObjectID()
+	items, err := s.Frontier().Add(jobID, "hh.ru", "go", []string{"a", "b", "c"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Frontier().Start(items[0].ID))
+	require.NoError(t, s.Frontier().Start(items[1].ID))
+	require.NoError(t, s.Frontier().Finish(items[1].ID, nil))
+
+	pending, err := s.Frontier().FindPending(jobID, "hh.ru", "go")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, links(pending))
+
+	require.NoError(t, s.Frontier().DeleteByJob(jobID))
+	pending, err = s.Frontier().FindPending(jobID, "hh.ru", "go")
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestFrontierRepository_Requeue(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("crawl_frontier")
+
+	jobID := primitive.NewObjectID()
+	items, err := s.Frontier().Add(jobID, "hh.ru", "go", []string{"in-flight", "done", "failed", "exhausted"})
+	require.NoError(t, err)
+	inFlight, done, failed, exhausted := items[0], items[1], items[2], items[3]
+	fetchErr := errors.New("connection reset")
+
+	require.NoError(t, s.Frontier().Start(inFlight.ID))
+	require.NoError(t, s.Frontier().Start(done.ID))
+	require.NoError(t, s.Frontier().Finish(done.ID, nil))
+	require.NoError(t, s.Frontier().Start(failed.ID))
+	require.NoError(t, s.Frontier().Finish(failed.ID, fetchErr))
+	for i := 0; i < model.MaxFetchAttempts; i++ {
+		require.NoError(t, s.Frontier().Start(exhausted.ID))
+		require.NoError(t, s.Frontier().Finish(exhausted.ID, fetchErr))
+	}
+
+	requeued, err := s.Frontier().Requeue(jobID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), requeued)
+
+	pending, err := s.Frontier().FindPending(jobID, "hh.ru", "go")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"in-flight", "failed"}, links(pending))
+	for _, item := range pending {
+		assert.Equal(t, 1, item.Attempts)
+	}
+}
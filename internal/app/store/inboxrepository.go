@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const inboxCollection = "inbox"
+
+// InboxRepository keeps saved search matches per user.
+type InboxRepository struct {
+	store *Store
+}
+
+func (r *InboxRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(inboxCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "search_id", Value: 1}, {Key: "vacancy.link", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create inbox indexes: %w", err)
+	}
+
+	return nil
+}
+
+// AddMatches stores new matches and returns the ones which were not in the
+// inbox yet. A vacancy is delivered once per saved search.
+func (r *InboxRepository) AddMatches(matches []model.SearchMatch) ([]model.SearchMatch, error) {
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	docs := make([]interface{}, 0, len(matches))
+	for i := range matches {
+		matches[i].ID = primitive.NewObjectID()
+		matches[i].CreatedAt = now
+		docs = append(docs, matches[i])
+	}
+
+	_, err := r.store.db.Collection(inboxCollection).InsertMany(context.Background(), docs, options.InsertMany().SetOrdered(false))
+	failed := make(map[int]bool)
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) {
+			return nil, fmt.Errorf("cannot add matches: %w", err)
+		}
+		for _, we := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(we) {
+				return nil, fmt.Errorf("cannot add matches: %w", we)
+			}
+			failed[we.Index] = true
+		}
+	}
+
+	added := make([]model.SearchMatch, 0, len(matches)-len(failed))
+	for i, m := range matches {
+		if !failed[i] {
+			added = append(added, m)
+		}
+	}
+
+	return added, nil
+}
+
+func (r *InboxRepository) FindByUser(userID primitive.ObjectID, unreadOnly bool) ([]model.SearchMatch, error) {
+	filter := bson.D{{Key: "user_id", Value: userID}}
+	if unreadOnly {
+		filter = append(filter, bson.E{Key: "read", Value: false})
+	}
+
+	matches := []model.SearchMatch{}
+	cursor, err := r.store.db.Collection(inboxCollection).Find(context.Background(), filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find matches: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &matches); err != nil {
+		return nil, fmt.Errorf("cannot decode matches: %w", err)
+	}
+
+	return matches, nil
+}
+
+// FindNotEmailed returns the user's matches which were not sent in a digest yet, oldest first.
+func (r *InboxRepository) FindNotEmailed(userID primitive.ObjectID) ([]model.SearchMatch, error) {
+	matches := []model.SearchMatch{}
+	cursor, err := r.store.db.Collection(inboxCollection).Find(context.Background(),
+		bson.D{{Key: "user_id", Value: userID}, {Key: "emailed", Value: bson.D{{Key: "$ne", Value: true}}}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find matches: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &matches); err != nil {
+		return nil, fmt.Errorf("cannot decode matches: %w", err)
+	}
+
+	return matches, nil
+}
+
+func (r *InboxRepository) MarkEmailed(ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := r.store.db.Collection(inboxCollection).UpdateMany(context.Background(),
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "emailed", Value: true}}}})
+	if err != nil {
+		return fmt.Errorf("cannot mark matches emailed: %w", err)
+	}
+
+	return nil
+}
+
+// MarkRead marks one match read, or every match of the user when id is nil.
+func (r *InboxRepository) MarkRead(userID primitive.ObjectID, id *primitive.ObjectID) (int64, error) {
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "read", Value: false}}
+	if id != nil {
+		filter = append(filter, bson.E{Key: "_id", Value: *id})
+	}
+
+	result, err := r.store.db.Collection(inboxCollection).UpdateMany(context.Background(), filter, bson.D{{Key: "$set", Value: bson.D{{Key: "read", Value: true}}}})
+	if err != nil {
+		return 0, fmt.Errorf("cannot mark matches read: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
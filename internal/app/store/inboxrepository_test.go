@@ -0,0 +1,42 @@
+package store_test
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInboxRepository_AddMatches(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("inbox")
+
+	userID, searchID := primitive.NewObjectID(), primitive.NewObjectID()
+	match := func(link string) model.SearchMatch {
+		return model.SearchMatch{UserID: userID, SearchID: searchID, Vacancy: model.Vacancy{Link: link}}
+	}
+
+	added, err := s.Inbox().AddMatches([]model.SearchMatch{match("https://hh.ru/vacancy/1")})
+	require.NoError(t, err)
+	assert.Len(t, added, 1)
+
+	// Only the vacancy which was not delivered to the search before is added.
+	added, err = s.Inbox().AddMatches([]model.SearchMatch{match("https://hh.ru/vacancy/1"), match("https://hh.ru/vacancy/2")})
+	require.NoError(t, err)
+	require.Len(t, added, 1)
+	assert.Equal(t, "https://hh.ru/vacancy/2", added[0].Vacancy.Link)
+
+	matches, err := s.Inbox().FindByUser(userID, true)
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+
+	count, err := s.Inbox().MarkRead(userID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	matches, err = s.Inbox().FindByUser(userID, true)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
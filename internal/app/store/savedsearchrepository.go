@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const savedSearchesCollection = "saved_searches"
+
+type SavedSearchRepository struct {
+	store *Store
+}
+
+func (r *SavedSearchRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(savedSearchesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create saved searches indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SavedSearchRepository) Create(search *model.SavedSearch) error {
+	if err := search.Validate(); err != nil {
+		return err
+	}
+	search.CreatedAt = time.Now().UTC()
+	search.UpdatedAt = search.CreatedAt
+
+	result, err := r.store.db.Collection(savedSearchesCollection).InsertOne(context.Background(), search)
+	if err != nil {
+		return fmt.Errorf("cannot create saved search: %w", err)
+	}
+
+	search.ID = result.InsertedID.(primitive.ObjectID)
+
+	return nil
+}
+
+func (r *SavedSearchRepository) Find(id, userID primitive.ObjectID) (*model.SavedSearch, error) {
+	var search model.SavedSearch
+	err := r.store.db.Collection(savedSearchesCollection).FindOne(context.Background(), bson.D{
+		{Key: "_id", Value: id},
+		{Key: "user_id", Value: userID},
+	}).Decode(&search)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find saved search: %w", err)
+	}
+
+	return &search, nil
+}
+
+func (r *SavedSearchRepository) FindByUser(userID primitive.ObjectID) ([]model.SavedSearch, error) {
+	return r.find(bson.D{{Key: "user_id", Value: userID}})
+}
+
+func (r *SavedSearchRepository) FindAll() ([]model.SavedSearch, error) {
+	return r.find(bson.D{})
+}
+
+func (r *SavedSearchRepository) find(filter bson.D) ([]model.SavedSearch, error) {
+	searches := []model.SavedSearch{}
+	cursor, err := r.store.db.Collection(savedSearchesCollection).Find(context.Background(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find saved searches: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &searches); err != nil {
+		return nil, fmt.Errorf("cannot decode saved searches: %w", err)
+	}
+
+	return searches, nil
+}
+
+func (r *SavedSearchRepository) Update(search *model.SavedSearch) error {
+	if err := search.Validate(); err != nil {
+		return err
+	}
+	search.UpdatedAt = time.Now().UTC()
+
+	result, err := r.store.db.Collection(savedSearchesCollection).UpdateOne(context.Background(),
+		bson.D{{Key: "_id", Value: search.ID}, {Key: "user_id", Value: search.UserID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "name", Value: search.Name},
+			{Key: "filters", Value: search.Filters},
+			{Key: "updated_at", Value: search.UpdatedAt},
+		}}})
+	if err != nil {
+		return fmt.Errorf("cannot update saved search: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the saved search together with its inbox entries.
+func (r *SavedSearchRepository) Delete(id, userID primitive.ObjectID) error {
+	result, err := r.store.db.Collection(savedSearchesCollection).DeleteOne(context.Background(), bson.D{
+		{Key: "_id", Value: id},
+		{Key: "user_id", Value: userID},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delete saved search: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	if _, err := r.store.db.Collection(inboxCollection).DeleteMany(context.Background(), bson.D{{Key: "search_id", Value: id}}); err != nil {
+		return fmt.Errorf("cannot delete saved search matches: %w", err)
+	}
+
+	return nil
+}
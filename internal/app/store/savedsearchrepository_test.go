@@ -0,0 +1,47 @@
+package store_test
+
+import (
+	"testing"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSavedSearchRepository_CRUD(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("saved_searches", "inbox")
+
+	userID, otherID := primitive.NewObjectID(), primitive.NewObjectID()
+	search := &model.SavedSearch{UserID: userID, Name: "go", Filters: model.Filters{Text: "go"}}
+	require.NoError(t, s.SavedSearch().Create(search))
+	assert.Error(t, s.SavedSearch().Create(&model.SavedSearch{UserID: userID, Name: "empty"}))
+
+	found, err := s.SavedSearch().Find(search.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "go", found.Filters.Text)
+	_, err = s.SavedSearch().Find(search.ID, otherID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	search.Filters.Location = "Москва"
+	require.NoError(t, s.SavedSearch().Update(search))
+	searches, err := s.SavedSearch().FindByUser(userID)
+	require.NoError(t, err)
+	require.Len(t, searches, 1)
+	assert.Equal(t, "Москва", searches[0].Filters.Location)
+
+	stolen := *search
+	stolen.UserID = otherID
+	assert.ErrorIs(t, s.SavedSearch().Update(&stolen), store.ErrRecordNotFound)
+	assert.ErrorIs(t, s.SavedSearch().Delete(search.ID, otherID), store.ErrRecordNotFound)
+
+	// Deleting a search empties its part of the inbox.
+	_, err = s.Inbox().AddMatches([]model.SearchMatch{{UserID: userID, SearchID: search.ID, Vacancy: model.Vacancy{Link: "https://hh.ru/vacancy/1"}}})
+	require.NoError(t, err)
+	require.NoError(t, s.SavedSearch().Delete(search.ID, userID))
+	matches, err := s.Inbox().FindByUser(userID, false)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
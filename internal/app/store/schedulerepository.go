@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const schedulesCollection = "schedules"
+
+type ScheduleRepository struct {
+	store *Store
+}
+
+func (r *ScheduleRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(schedulesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create schedules indexes: %w", err)
+	}
+
+	return nil
+}
+
+// Save creates or replaces the schedule with the same name.
+func (r *ScheduleRepository) Save(schedule *model.Schedule) error {
+	_, err := r.store.db.Collection(schedulesCollection).ReplaceOne(context.Background(),
+		bson.D{{Key: "name", Value: schedule.Name}}, schedule, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("cannot save schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ScheduleRepository) FindAll() ([]model.Schedule, error) {
+	schedules := []model.Schedule{}
+	cursor, err := r.store.db.Collection(schedulesCollection).Find(context.Background(), bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find schedules: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &schedules); err != nil {
+		return nil, fmt.Errorf("cannot decode schedules: %w", err)
+	}
+
+	return schedules, nil
+}
@@ -12,11 +12,22 @@ import (
 
 // Store ...
 type Store struct {
-	config            *Config
-	client            *mongo.Client
-	db                *mongo.Database
-	UserRepository    *UserRepository
-	VacancyRepository *VacancyRepository
+	config                    *Config
+	client                    *mongo.Client
+	db                        *mongo.Database
+	UserRepository            *UserRepository
+	VacancyRepository         *VacancyRepository
+	TokenRepository           *TokenRepository
+	APIKeyRepository          *APIKeyRepository
+	SavedSearchRepository     *SavedSearchRepository
+	InboxRepository           *InboxRepository
+	ApplicationRepository     *ApplicationRepository
+	WebhookRepository         *WebhookRepository
+	WebhookDeliveryRepository *WebhookDeliveryRepository
+	CrawlJobRepository        *CrawlJobRepository
+	ScheduleRepository        *ScheduleRepository
+	FrontierRepository        *FrontierRepository
+	UnparsedPageRepository    *UnparsedPageRepository
 }
 
 // New ...
@@ -39,10 +50,65 @@ func (s *Store) Open() error {
 		log.Fatal("Ping error:", err)
 	}
 
-	db := mongoClient.Database("vacancy_parser")
+	db := mongoClient.Database(s.config.Database)
+	s.client = mongoClient
 	s.db = db
 	fmt.Println("Connected to MongoDB!")
 
+	if err := s.createIndexes(); err != nil {
+		return err
+	}
+
+	if err := s.User().migrateLegacy(context.Background()); err != nil {
+		return err
+	}
+
+	return s.Vacancy().backfillSalaryMax(context.Background())
+}
+
+func (s *Store) createIndexes() error {
+	ctx := context.Background()
+
+	if err := s.User().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.Vacancy().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.Token().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.APIKey().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.SavedSearch().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.Inbox().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.Application().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.Webhook().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.WebhookDelivery().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.CrawlJob().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.Schedule().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.Frontier().createIndexes(ctx); err != nil {
+		return err
+	}
+	if err := s.UnparsedPage().createIndexes(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -74,3 +140,135 @@ func (s *Store) Vacancy() *VacancyRepository {
 
 	return s.VacancyRepository
 }
+
+func (s *Store) Token() *TokenRepository {
+	if s.TokenRepository != nil {
+		return s.TokenRepository
+	}
+
+	s.TokenRepository = &TokenRepository{
+		store: s,
+	}
+
+	return s.TokenRepository
+}
+
+func (s *Store) APIKey() *APIKeyRepository {
+	if s.APIKeyRepository != nil {
+		return s.APIKeyRepository
+	}
+
+	s.APIKeyRepository = &APIKeyRepository{
+		store: s,
+	}
+
+	return s.APIKeyRepository
+}
+
+func (s *Store) SavedSearch() *SavedSearchRepository {
+	if s.SavedSearchRepository != nil {
+		return s.SavedSearchRepository
+	}
+
+	s.SavedSearchRepository = &SavedSearchRepository{
+		store: s,
+	}
+
+	return s.SavedSearchRepository
+}
+
+func (s *Store) Inbox() *InboxRepository {
+	if s.InboxRepository != nil {
+		return s.InboxRepository
+	}
+
+	s.InboxRepository = &InboxRepository{
+		store: s,
+	}
+
+	return s.InboxRepository
+}
+
+func (s *Store) Application() *ApplicationRepository {
+	if s.ApplicationRepository != nil {
+		return s.ApplicationRepository
+	}
+
+	s.ApplicationRepository = &ApplicationRepository{
+		store: s,
+	}
+
+	return s.ApplicationRepository
+}
+
+func (s *Store) Webhook() *WebhookRepository {
+	if s.WebhookRepository != nil {
+		return s.WebhookRepository
+	}
+
+	s.WebhookRepository = &WebhookRepository{
+		store: s,
+	}
+
+	return s.WebhookRepository
+}
+
+func (s *Store) WebhookDelivery() *WebhookDeliveryRepository {
+	if s.WebhookDeliveryRepository != nil {
+		return s.WebhookDeliveryRepository
+	}
+
+	s.WebhookDeliveryRepository = &WebhookDeliveryRepository{
+		store: s,
+	}
+
+	return s.WebhookDeliveryRepository
+}
+
+func (s *Store) CrawlJob() *CrawlJobRepository {
+	if s.CrawlJobRepository != nil {
+		return s.CrawlJobRepository
+	}
+
+	s.CrawlJobRepository = &CrawlJobRepository{
+		store: s,
+	}
+
+	return s.CrawlJobRepository
+}
+
+func (s *Store) Schedule() *ScheduleRepository {
+	if s.ScheduleRepository != nil {
+		return s.ScheduleRepository
+	}
+
+	s.ScheduleRepository = &ScheduleRepository{
+		store: s,
+	}
+
+	return s.ScheduleRepository
+}
+
+func (s *Store) Frontier() *FrontierRepository {
+	if s.FrontierRepository != nil {
+		return s.FrontierRepository
+	}
+
+	s.FrontierRepository = &FrontierRepository{
+		store: s,
+	}
+
+	return s.FrontierRepository
+}
+
+func (s *Store) UnparsedPage() *UnparsedPageRepository {
+	if s.UnparsedPageRepository != nil {
+		return s.UnparsedPageRepository
+	}
+
+	s.UnparsedPageRepository = &UnparsedPageRepository{
+		store: s,
+	}
+
+	return s.UnparsedPageRepository
+}
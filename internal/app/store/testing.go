@@ -3,24 +3,34 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// TestStore opens a store on a separate test database. The returned function
+// drops the given collections and closes the store. Tests are skipped when
+// no MongoDB runs at databaseURL.
 func TestStore(t *testing.T, databaseURL string) (*Store, func(...string)) {
 	t.Helper()
 
+	if err := ping(databaseURL); err != nil {
+		t.Skipf("mongodb is not available at %s: %v", databaseURL, err)
+	}
+
 	config := NewConfig()
 	config.DatabaseURL = databaseURL
+	config.Database = "vacancy_parser_test"
+	config.BatchFlushInterval = 10
 	s := New(config)
 	if err := s.Open(); err != nil {
 		t.Fatal(err)
 	}
 
-	return s, func(docs ...string) {
-		if len(docs) > 0 {
-			if _, err := s.db.Collection("users").Find(context.Background(), bson.M{"name": bson.M{"$in": docs}}); err != nil {
+	return s, func(collections ...string) {
+		for _, name := range collections {
+			if err := s.db.Collection(name).Drop(context.Background()); err != nil {
 				t.Fatal(err)
 			}
 		}
@@ -29,6 +39,15 @@ func TestStore(t *testing.T, databaseURL string) (*Store, func(...string)) {
 	}
 }
 
-func newMongoClient() *mongo.Client {
-	return nil
+func ping(databaseURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(databaseURL).SetServerSelectionTimeout(2*time.Second))
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(context.Background())
+
+	return client.Ping(ctx, nil)
 }
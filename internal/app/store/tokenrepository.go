@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const revokedTokensCollection = "revoked_tokens"
+
+// TokenRepository keeps revoked token ids until the tokens expire on their own.
+type TokenRepository struct {
+	store *Store
+}
+
+type revokedToken struct {
+	ID        string    `bson:"_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func (r *TokenRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(revokedTokensCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create revoked tokens indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) Revoke(id string, expiresAt time.Time) error {
+	_, err := r.store.db.Collection(revokedTokensCollection).ReplaceOne(context.Background(),
+		bson.D{{Key: "_id", Value: id}},
+		revokedToken{ID: id, ExpiresAt: expiresAt},
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("cannot revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// Consume revokes a single-use token. Only the first of concurrent calls with
+// the same id succeeds, the others get ErrTokenReused.
+func (r *TokenRepository) Consume(id string, expiresAt time.Time) error {
+	_, err := r.store.db.Collection(revokedTokensCollection).InsertOne(context.Background(), revokedToken{ID: id, ExpiresAt: expiresAt})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrTokenReused
+		}
+		return fmt.Errorf("cannot revoke token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) IsRevoked(id string) (bool, error) {
+	err := r.store.db.Collection(revokedTokensCollection).FindOne(context.Background(), bson.D{{Key: "_id", Value: id}}).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot check token: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const unparsedPagesCollection = "unparsed_pages"
+
+// UnparsedPageRepository keeps the archived pages the crawler could not
+// parse until a reparse recovers them.
+type UnparsedPageRepository struct {
+	store *Store
+}
+
+func (r *UnparsedPageRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(unparsedPagesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "link", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create unparsed pages indexes: %w", err)
+	}
+
+	return nil
+}
+
+// Save creates or replaces the unparsed page of the same link.
+func (r *UnparsedPageRepository) Save(page *model.UnparsedPage) error {
+	page.UpdatedAt = time.Now().UTC()
+	_, err := r.store.db.Collection(unparsedPagesCollection).ReplaceOne(context.Background(),
+		bson.D{{Key: "link", Value: page.Link}}, page, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("cannot save unparsed page: %w", err)
+	}
+
+	return nil
+}
+
+// Each calls f with every unparsed page. An error returned by f stops the iteration.
+func (r *UnparsedPageRepository) Each(f func(page model.UnparsedPage) error) error {
+	cursor, err := r.store.db.Collection(unparsedPagesCollection).Find(context.Background(), bson.D{})
+	if err != nil {
+		return fmt.Errorf("cannot find unparsed pages: %w", err)
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var page model.UnparsedPage
+		if err := cursor.Decode(&page); err != nil {
+			return fmt.Errorf("cannot decode unparsed page: %w", err)
+		}
+		if err := f(page); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// Delete drops the unparsed page of the link.
+func (r *UnparsedPageRepository) Delete(link string) error {
+	_, err := r.store.db.Collection(unparsedPagesCollection).DeleteOne(context.Background(), bson.D{{Key: "link", Value: link}})
+	if err != nil {
+		return fmt.Errorf("cannot delete unparsed page: %w", err)
+	}
+
+	return nil
+}
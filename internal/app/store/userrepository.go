@@ -2,29 +2,144 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"time"
 	"vacancy-parser/internal/app/model"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	usersCollection = "users"
+	// legacyUsersCollection held the users, with plaintext passwords, before
+	// they moved to usersCollection.
+	legacyUsersCollection = "test"
 )
 
 type UserRepository struct {
 	store *Store
 }
 
+func (r *UserRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(usersCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create users indexes: %w", err)
+	}
+
+	return nil
+}
+
+// migrateLegacy moves the users left in the legacy collection to the users
+// one, hashing their plaintext passwords, and drops the legacy collection.
+// Users already registered under the same email keep their account.
+func (r *UserRepository) migrateLegacy(ctx context.Context) error {
+	names, err := r.store.db.ListCollectionNames(ctx, bson.D{{Key: "name", Value: legacyUsersCollection}})
+	if err != nil {
+		return fmt.Errorf("cannot find legacy users: %w", err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	legacy := r.store.db.Collection(legacyUsersCollection)
+	cursor, err := legacy.Find(ctx, bson.D{})
+	if err != nil {
+		return fmt.Errorf("cannot find legacy users: %w", err)
+	}
+	var docs []struct {
+		Email    string `bson:"email"`
+		Password string `bson:"password"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return fmt.Errorf("cannot decode legacy users: %w", err)
+	}
+
+	migrated, skipped := 0, 0
+	for _, doc := range docs {
+		// Legacy passwords predate the validation rules, so they are only
+		// required to be set.
+		user := &model.User{Email: model.NormalizeEmail(doc.Email), Password: doc.Password}
+		if user.Email == "" || user.Password == "" {
+			skipped++
+			continue
+		}
+		if err := user.BeforeCreate(); err != nil {
+			return err
+		}
+
+		_, err := r.store.db.Collection(usersCollection).InsertOne(ctx, user)
+		if mongo.IsDuplicateKeyError(err) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot migrate user: %w", err)
+		}
+		migrated++
+	}
+
+	if err := legacy.Drop(ctx); err != nil {
+		return fmt.Errorf("cannot drop legacy users: %w", err)
+	}
+	log.Printf("migrated legacy users: %d, skipped: %d\n", migrated, skipped)
+
+	return nil
+}
+
+// CreateUser validates the user, hashes its password and stores it.
 func (r *UserRepository) CreateUser(user *model.User) (interface{}, error) {
-	result, err := r.store.db.Collection("test").InsertOne(context.Background(), user)
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+	if err := user.BeforeCreate(); err != nil {
+		return nil, err
+	}
+
+	result, err := r.store.db.Collection(usersCollection).InsertOne(context.Background(), user)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEmailTaken
+		}
 		return nil, fmt.Errorf("cannot create user: %w", err)
 	}
 
+	user.ID = result.InsertedID.(primitive.ObjectID)
+
 	return result.InsertedID, nil
 }
 
 func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 	var user model.User
-	err := r.store.db.Collection("test").FindOne(context.Background(), bson.D{{Key: "email", Value: email}}).Decode(&user)
+	err := r.store.db.Collection(usersCollection).FindOne(context.Background(), bson.D{{Key: "email", Value: model.NormalizeEmail(email)}}).Decode(&user)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find user: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) FindByID(id string) (*model.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrRecordNotFound
+	}
+
+	var user model.User
+	err = r.store.db.Collection(usersCollection).FindOne(context.Background(), bson.D{{Key: "_id", Value: objectID}}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
 		return nil, fmt.Errorf("cannot find user: %w", err)
 	}
 	return &user, nil
@@ -32,7 +147,7 @@ func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 
 func (r *UserRepository) FindAll() ([]model.User, error) {
 	var users []model.User
-	cursor, err := r.store.db.Collection("test").Find(context.Background(), bson.D{})
+	cursor, err := r.store.db.Collection(usersCollection).Find(context.Background(), bson.D{})
 	if err != nil {
 		return nil, fmt.Errorf("cannot find users: %w", err)
 	}
@@ -43,35 +158,218 @@ func (r *UserRepository) FindAll() ([]model.User, error) {
 	return users, nil
 }
 
+// UpdateUserByEmail stores the email, name and password of updUser for the
+// user currently registered under userEmail. A new password is hashed first.
 func (r *UserRepository) UpdateUserByEmail(userEmail string, updUser *model.User) (int64, error) {
+	if err := updUser.Validate(); err != nil {
+		return 0, err
+	}
+	if err := updUser.HashPassword(); err != nil {
+		return 0, err
+	}
 
-	result, err := r.store.db.Collection("test").UpdateOne(context.Background(), bson.D{{Key: "email", Value: userEmail}}, bson.D{{Key: "$set", Value: updUser}})
-
+	update := bson.D{
+		{Key: "email", Value: updUser.Email},
+		{Key: "name", Value: updUser.Name},
+		{Key: "password_hash", Value: updUser.PasswordHash},
+	}
+	result, err := r.store.db.Collection(usersCollection).UpdateOne(context.Background(), bson.D{{Key: "email", Value: model.NormalizeEmail(userEmail)}}, bson.D{{Key: "$set", Value: update}})
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return 0, ErrEmailTaken
+		}
 		return 0, fmt.Errorf("cannot update user: %w", err)
 	}
+	if result.MatchedCount == 0 {
+		return 0, ErrRecordNotFound
+	}
 
 	return result.ModifiedCount, nil
 }
 
-func (r *UserRepository) DeleteUserByEmail(userEmail string) (int64, error) {
+func (r *UserRepository) UpdateRoles(userEmail string, roles []string) (int64, error) {
+	for _, role := range roles {
+		if !model.ValidRole(role) {
+			return 0, fmt.Errorf("%w: unknown role %q", model.ErrValidation, role)
+		}
+	}
 
-	result, err := r.store.db.Collection("test").DeleteOne(context.Background(), bson.D{{Key: "email", Value: userEmail}})
+	result, err := r.store.db.Collection(usersCollection).UpdateOne(context.Background(), bson.D{{Key: "email", Value: model.NormalizeEmail(userEmail)}}, bson.D{{Key: "$set", Value: bson.D{{Key: "roles", Value: roles}}}})
+	if err != nil {
+		return 0, fmt.Errorf("cannot update roles: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return 0, ErrRecordNotFound
+	}
 
+	return result.ModifiedCount, nil
+}
+
+// GrantRole adds the role to the roles of the user.
+func (r *UserRepository) GrantRole(userEmail string, role string) (int64, error) {
+	if !model.ValidRole(role) {
+		return 0, fmt.Errorf("%w: unknown role %q", model.ErrValidation, role)
+	}
+
+	result, err := r.store.db.Collection(usersCollection).UpdateOne(context.Background(), bson.D{{Key: "email", Value: model.NormalizeEmail(userEmail)}}, bson.D{{Key: "$addToSet", Value: bson.D{{Key: "roles", Value: role}}}})
 	if err != nil {
+		return 0, fmt.Errorf("cannot update roles: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return 0, ErrRecordNotFound
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// FindDigestRecipients returns users who have not switched the email digest off.
+func (r *UserRepository) FindDigestRecipients() ([]model.User, error) {
+	var users []model.User
+	cursor, err := r.store.db.Collection(usersCollection).Find(context.Background(), bson.D{{Key: "digest_frequency", Value: bson.D{{Key: "$ne", Value: model.DigestOff}}}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find users: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return nil, fmt.Errorf("cannot decode users: %w", err)
+	}
+	return users, nil
+}
+
+func (r *UserRepository) UpdateDigestFrequency(id primitive.ObjectID, frequency string) error {
+	if frequency == "" || !model.ValidDigestFrequency(frequency) {
+		return fmt.Errorf("%w: unknown digest frequency %q", model.ErrValidation, frequency)
+	}
+
+	result, err := r.store.db.Collection(usersCollection).UpdateOne(context.Background(), bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "digest_frequency", Value: frequency}}}})
+	if err != nil {
+		return fmt.Errorf("cannot update digest frequency: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) SetLastDigestAt(id primitive.ObjectID, at time.Time) error {
+	_, err := r.store.db.Collection(usersCollection).UpdateOne(context.Background(), bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "last_digest_at", Value: at}}}})
+	if err != nil {
+		return fmt.Errorf("cannot update last digest time: %w", err)
+	}
+
+	return nil
+}
+
+// SetTelegramLinkCode stores a one-time code the user sends to the bot to link the chat.
+func (r *UserRepository) SetTelegramLinkCode(id primitive.ObjectID, code string, expires time.Time) error {
+	result, err := r.store.db.Collection(usersCollection).UpdateOne(context.Background(), bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "telegram_link_code", Value: code},
+		{Key: "telegram_link_expires", Value: expires},
+	}}})
+	if err != nil {
+		return fmt.Errorf("cannot set telegram link code: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// LinkTelegram attaches the chat to the user holding the not expired code.
+// The code is consumed and the chat is detached from any other user.
+func (r *UserRepository) LinkTelegram(code string, chatID int64) (*model.User, error) {
+	coll := r.store.db.Collection(usersCollection)
+
+	var user model.User
+	err := coll.FindOneAndUpdate(context.Background(),
+		bson.D{
+			{Key: "telegram_link_code", Value: code},
+			{Key: "telegram_link_expires", Value: bson.D{{Key: "$gt", Value: time.Now().UTC()}}},
+		},
+		bson.D{
+			{Key: "$set", Value: bson.D{{Key: "telegram_chat_id", Value: chatID}}},
+			{Key: "$unset", Value: bson.D{{Key: "telegram_link_code", Value: ""}, {Key: "telegram_link_expires", Value: ""}}},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot link telegram: %w", err)
+	}
+
+	_, err = coll.UpdateMany(context.Background(),
+		bson.D{{Key: "telegram_chat_id", Value: chatID}, {Key: "_id", Value: bson.D{{Key: "$ne", Value: user.ID}}}},
+		bson.D{{Key: "$unset", Value: bson.D{{Key: "telegram_chat_id", Value: ""}}}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot link telegram: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) FindByTelegramChatID(chatID int64) (*model.User, error) {
+	var user model.User
+	err := r.store.db.Collection(usersCollection).FindOne(context.Background(), bson.D{{Key: "telegram_chat_id", Value: chatID}}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find user: %w", err)
+	}
+	return &user, nil
+}
+
+// FindTelegramLinked returns the users among ids who linked a Telegram chat.
+func (r *UserRepository) FindTelegramLinked(ids []primitive.ObjectID) ([]model.User, error) {
+	var users []model.User
+	cursor, err := r.store.db.Collection(usersCollection).Find(context.Background(), bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
+		{Key: "telegram_chat_id", Value: bson.D{{Key: "$exists", Value: true}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find users: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return nil, fmt.Errorf("cannot decode users: %w", err)
+	}
+	return users, nil
+}
+
+// DeleteUserByEmail deletes the user and revokes their API keys.
+func (r *UserRepository) DeleteUserByEmail(userEmail string) (int64, error) {
+	var user model.User
+	err := r.store.db.Collection(usersCollection).FindOneAndDelete(context.Background(),
+		bson.D{{Key: "email", Value: model.NormalizeEmail(userEmail)}}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
 		return 0, fmt.Errorf("cannot delete user: %w", err)
 	}
 
-	return result.DeletedCount, nil
+	if err := r.store.APIKey().RevokeByOwner(&user.ID); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
 }
 
+// DeleteAll deletes every user and revokes every API key.
 func (r *UserRepository) DeleteAll() (int64, error) {
 
-	result, err := r.store.db.Collection("test").DeleteMany(context.Background(), bson.D{})
+	result, err := r.store.db.Collection(usersCollection).DeleteMany(context.Background(), bson.D{})
 
 	if err != nil {
 		return 0, fmt.Errorf("cannot delete users: %w", err)
 	}
 
+	if err := r.store.APIKey().RevokeByOwner(nil); err != nil {
+		return 0, err
+	}
+
 	return result.DeletedCount, nil
 }
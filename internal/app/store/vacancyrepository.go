@@ -2,10 +2,14 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 	"vacancy-parser/internal/app/model"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -13,7 +17,100 @@ type VacancyRepository struct {
 	store *Store
 }
 
+// BulkItemError describes a vacancy that could not be written as part of a batch.
+type BulkItemError struct {
+	Vacancy *model.Vacancy
+	Err     error
+}
+
+func (e BulkItemError) Error() string {
+	return fmt.Sprintf("cannot write vacancy %s: %v", e.Vacancy.Link, e.Err)
+}
+
+// BulkResult holds counters of a bulk upsert, every vacancy written, the ones
+// which were not stored before, the stored ones whose content changed or
+// which were closed, and the items which failed. Updated counts the
+// changed ones only.
+type BulkResult struct {
+	Inserted int64
+	Updated  int64
+	Written  []*model.Vacancy
+	Created  []*model.Vacancy
+	Changed  []*model.Vacancy
+	Errors   []BulkItemError
+}
+
+// openVacancy matches vacancies which were seen by the latest crawl.
+var openVacancy = bson.E{Key: "closed", Value: bson.D{{Key: "$ne", Value: true}}}
+
+func (r *VacancyRepository) createIndexes(ctx context.Context) error {
+	if err := r.removeDuplicates(ctx); err != nil {
+		return err
+	}
+
+	_, err := r.store.db.Collection("vacancies").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "link", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "closed", Value: 1}}},
+		{Keys: bson.D{{Key: "last_fetched_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create vacancies indexes: %w", err)
+	}
+
+	return nil
+}
+
+// removeDuplicates keeps the first stored vacancy of every link, so the
+// unique link index can be built over vacancies written concurrently
+// before it existed.
+func (r *VacancyRepository) removeDuplicates(ctx context.Context) error {
+	coll := r.store.db.Collection("vacancies")
+	cursor, err := coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$link"},
+			{Key: "ids", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("cannot find duplicate vacancies: %w", err)
+	}
+
+	var groups []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return fmt.Errorf("cannot decode duplicate vacancies: %w", err)
+	}
+
+	var duplicates []primitive.ObjectID
+	for _, g := range groups {
+		duplicates = append(duplicates, g.IDs[1:]...)
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	if _, err := coll.DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: duplicates}}}}); err != nil {
+		return fmt.Errorf("cannot delete duplicate vacancies: %w", err)
+	}
+
+	return nil
+}
+
 func (r *VacancyRepository) InsertVacancy(vacancy *model.Vacancy) (interface{}, error) {
+	now := time.Now().UTC()
+	vacancy.Fingerprint = vacancy.ComputeFingerprint()
+	vacancy.SetSalaryMax()
+	vacancy.FirstSeenAt = now
+	vacancy.LastSeenAt = now
+	vacancy.LastFetchedAt = now
+
 	result, err := r.store.db.Collection("vacancies").InsertOne(context.Background(), vacancy)
 	if err != nil {
 		return nil, fmt.Errorf("cannot insert vacancy: %w", err)
@@ -22,9 +119,365 @@ func (r *VacancyRepository) InsertVacancy(vacancy *model.Vacancy) (interface{},
 	return result.InsertedID, nil
 }
 
-func (r *VacancyRepository) FindAllVacancy() ([]model.Vacancy, error) {
-	var vacancies []model.Vacancy
-	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(), bson.D{})
+// UpsertVacancies writes vacancies in a single unordered bulk request, using
+// the link as the vacancy identity. Written vacancies are marked open, seen
+// and fetched now. A failure of one item does not stop the others, failed items are
+// reported in BulkResult.Errors.
+func (r *VacancyRepository) UpsertVacancies(vacancies []*model.Vacancy) (*BulkResult, error) {
+	res := &BulkResult{}
+	if len(vacancies) == 0 {
+		return res, nil
+	}
+
+	links := make([]string, 0, len(vacancies))
+	for _, v := range vacancies {
+		links = append(links, v.Link)
+	}
+	known, err := r.findKnown(links)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	models := make([]mongo.WriteModel, 0, len(vacancies))
+	for _, v := range vacancies {
+		v.Fingerprint = v.ComputeFingerprint()
+		v.SetSalaryMax()
+		v.Closed = false
+		v.ClosedAt = nil
+		v.FirstSeenAt = time.Time{}
+		v.LastSeenAt = now
+		v.LastFetchedAt = now
+
+		// Tags are added to the stored ones, so they are kept out of $set.
+		tags := v.Tags
+		v.Tags = nil
+		set, err := bson.Marshal(v)
+		v.Tags = tags
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode vacancy: %w", err)
+		}
+
+		update := bson.D{
+			{Key: "$set", Value: bson.Raw(set)},
+			{Key: "$setOnInsert", Value: bson.D{{Key: "first_seen_at", Value: now}}},
+			{Key: "$unset", Value: bson.D{{Key: "closed_at", Value: ""}}},
+		}
+		if len(tags) > 0 {
+			update = append(update, bson.E{Key: "$addToSet", Value: bson.D{{Key: "tags", Value: bson.D{{Key: "$each", Value: tags}}}}})
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "link", Value: v.Link}}).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+
+	failed := make(map[int]bool)
+	result, err := r.store.db.Collection("vacancies").BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+			return nil, fmt.Errorf("cannot upsert vacancies: %w", err)
+		}
+		for _, we := range bulkErr.WriteErrors {
+			failed[we.Index] = true
+			res.Errors = append(res.Errors, BulkItemError{Vacancy: vacancies[we.Index], Err: we})
+		}
+	}
+	if result != nil {
+		res.Inserted = result.UpsertedCount
+	}
+
+	for i, v := range vacancies {
+		if failed[i] {
+			continue
+		}
+		res.Written = append(res.Written, v)
+		if result != nil {
+			if _, ok := result.UpsertedIDs[int64(i)]; ok {
+				v.FirstSeenAt = now
+				res.Created = append(res.Created, v)
+				continue
+			}
+		}
+		if prev, ok := known[v.Link]; ok {
+			v.FirstSeenAt = prev.FirstSeenAt
+			if prev.Closed || prev.Fingerprint != v.Fingerprint {
+				res.Changed = append(res.Changed, v)
+			}
+		}
+	}
+	res.Updated = int64(len(res.Changed))
+
+	return res, nil
+}
+
+// findKnown returns the stored state of the vacancies with the given links.
+func (r *VacancyRepository) findKnown(links []string) (map[string]model.Vacancy, error) {
+	opts := options.Find().SetProjection(bson.D{
+		{Key: "link", Value: 1},
+		{Key: "fingerprint", Value: 1},
+		{Key: "closed", Value: 1},
+		{Key: "first_seen_at", Value: 1},
+	})
+	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(),
+		bson.D{{Key: "link", Value: bson.D{{Key: "$in", Value: links}}}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find vacancies: %w", err)
+	}
+
+	var stored []model.Vacancy
+	if err := cursor.All(context.Background(), &stored); err != nil {
+		return nil, fmt.Errorf("cannot decode vacancies: %w", err)
+	}
+
+	known := make(map[string]model.Vacancy, len(stored))
+	for _, v := range stored {
+		known[v.Link] = v
+	}
+
+	return known, nil
+}
+
+// backfillSalaryMax fills the salary bound of vacancies stored before it was kept.
+func (r *VacancyRepository) backfillSalaryMax(ctx context.Context) error {
+	coll := r.store.db.Collection("vacancies")
+	cursor, err := coll.Find(ctx, bson.D{{Key: "salary_max", Value: bson.D{{Key: "$exists", Value: false}}}},
+		options.Find().SetProjection(bson.D{{Key: "salary", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("cannot find vacancies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var models []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var v struct {
+			ID     primitive.ObjectID `bson:"_id"`
+			Salary string             `bson:"salary"`
+		}
+		if err := cursor.Decode(&v); err != nil {
+			return fmt.Errorf("cannot decode vacancy: %w", err)
+		}
+		from, to := model.ParseSalary(v.Salary)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: v.ID}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "salary_max", Value: max(from, to)}}}}))
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cannot find vacancies: %w", err)
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	if _, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("cannot backfill salaries: %w", err)
+	}
+
+	return nil
+}
+
+// EachArchived calls f with every vacancy, open or closed, which has an
+// archived page. An error returned by f stops the iteration.
+func (r *VacancyRepository) EachArchived(f func(v model.Vacancy) error) error {
+	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(),
+		bson.D{{Key: "raw", Value: bson.D{{Key: "$exists", Value: true}}}})
+	if err != nil {
+		return fmt.Errorf("cannot find archived vacancies: %w", err)
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var v model.Vacancy
+		if err := cursor.Decode(&v); err != nil {
+			return fmt.Errorf("cannot decode vacancy: %w", err)
+		}
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// UpdateContent saves the parsed content of stored vacancies, leaving their
+// tags and crawl state alone, and returns the vacancies whose content changed.
+func (r *VacancyRepository) UpdateContent(vacancies []*model.Vacancy) ([]*model.Vacancy, error) {
+	if len(vacancies) == 0 {
+		return nil, nil
+	}
+
+	links := make([]string, 0, len(vacancies))
+	for _, v := range vacancies {
+		links = append(links, v.Link)
+	}
+	known, err := r.findKnown(links)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []*model.Vacancy
+	var models []mongo.WriteModel
+	for _, v := range vacancies {
+		v.Fingerprint = v.ComputeFingerprint()
+		v.SetSalaryMax()
+		if prev, ok := known[v.Link]; !ok || prev.Fingerprint == v.Fingerprint {
+			continue
+		}
+		changed = append(changed, v)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "link", Value: v.Link}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{
+				{Key: "title", Value: v.Title},
+				{Key: "location", Value: v.Location},
+				{Key: "company", Value: v.Company},
+				{Key: "hardskills", Value: v.HardSkills},
+				{Key: "site", Value: v.Site},
+				{Key: "date", Value: v.Date},
+				{Key: "salary", Value: v.Salary},
+				{Key: "salary_max", Value: v.SalaryMax},
+				{Key: "experience", Value: v.Experience},
+				{Key: "mainlanguage", Value: v.MainLanguage},
+				{Key: "fingerprint", Value: v.Fingerprint},
+			}}}))
+	}
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	if _, err := r.store.db.Collection("vacancies").BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return nil, fmt.Errorf("cannot update vacancies: %w", err)
+	}
+
+	return changed, nil
+}
+
+// FindFresh returns which of the links belong to open vacancies fetched
+// since the given time.
+func (r *VacancyRepository) FindFresh(links []string, since time.Time) (map[string]bool, error) {
+	opts := options.Find().SetProjection(bson.D{{Key: "link", Value: 1}})
+	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(), bson.D{
+		{Key: "link", Value: bson.D{{Key: "$in", Value: links}}},
+		{Key: "last_fetched_at", Value: bson.D{{Key: "$gte", Value: since}}},
+		openVacancy,
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find vacancies: %w", err)
+	}
+
+	var stored []model.Vacancy
+	if err := cursor.All(context.Background(), &stored); err != nil {
+		return nil, fmt.Errorf("cannot decode vacancies: %w", err)
+	}
+
+	fresh := make(map[string]bool, len(stored))
+	for _, v := range stored {
+		fresh[v.Link] = true
+	}
+
+	return fresh, nil
+}
+
+// MarkSeen records that a crawl listed the vacancies.
+func (r *VacancyRepository) MarkSeen(links []string) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	_, err := r.store.db.Collection("vacancies").UpdateMany(context.Background(),
+		bson.D{{Key: "link", Value: bson.D{{Key: "$in", Value: links}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "last_seen_at", Value: time.Now().UTC()}}}})
+	if err != nil {
+		return fmt.Errorf("cannot mark vacancies seen: %w", err)
+	}
+
+	return nil
+}
+
+// CloseUnseen closes the open vacancies found by the query which were last
+// seen before the given time and returns them. Vacancies stored before
+// queries were tagged are matched by their main language. Vacancies other
+// queries found too only lose the tag of this one, as those queries may
+// still list them.
+func (r *VacancyRepository) CloseUnseen(query model.CrawlQuery, before time.Time) ([]model.Vacancy, error) {
+	coll := r.store.db.Collection("vacancies")
+	filter := bson.D{
+		openVacancy,
+		{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "tags", Value: query.Tag()}},
+				bson.D{{Key: "tags", Value: bson.D{{Key: "$exists", Value: false}}}, {Key: "mainlanguage", Value: query.Text}},
+			}}},
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "last_seen_at", Value: bson.D{{Key: "$lt", Value: before}}}},
+				bson.D{{Key: "last_seen_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+			}}},
+		}},
+	}
+
+	unseen := []model.Vacancy{}
+	cursor, err := coll.Find(context.Background(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find unseen vacancies: %w", err)
+	}
+	if err := cursor.All(context.Background(), &unseen); err != nil {
+		return nil, fmt.Errorf("cannot decode vacancies: %w", err)
+	}
+
+	now := time.Now().UTC()
+	vacancies := []model.Vacancy{}
+	var closed, untagged []string
+	for _, v := range unseen {
+		if len(v.Tags) > 1 {
+			untagged = append(untagged, v.Link)
+			continue
+		}
+		v.Closed = true
+		v.ClosedAt = &now
+		vacancies = append(vacancies, v)
+		closed = append(closed, v.Link)
+	}
+
+	if len(untagged) > 0 {
+		_, err = coll.UpdateMany(context.Background(), bson.D{{Key: "link", Value: bson.D{{Key: "$in", Value: untagged}}}},
+			bson.D{{Key: "$pull", Value: bson.D{{Key: "tags", Value: query.Tag()}}}})
+		if err != nil {
+			return nil, fmt.Errorf("cannot untag vacancies: %w", err)
+		}
+	}
+	if len(closed) > 0 {
+		_, err = coll.UpdateMany(context.Background(), bson.D{{Key: "link", Value: bson.D{{Key: "$in", Value: closed}}}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "closed", Value: true}, {Key: "closed_at", Value: now}}}})
+		if err != nil {
+			return nil, fmt.Errorf("cannot close vacancies: %w", err)
+		}
+	}
+
+	return vacancies, nil
+}
+
+// Close closes the open vacancy with the link, which is gone from the site.
+func (r *VacancyRepository) Close(link string) (*model.Vacancy, error) {
+	var vacancy model.Vacancy
+	err := r.store.db.Collection("vacancies").FindOneAndUpdate(context.Background(),
+		bson.D{{Key: "link", Value: link}, openVacancy},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "closed", Value: true}, {Key: "closed_at", Value: time.Now().UTC()}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&vacancy)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot close vacancy: %w", err)
+	}
+
+	return &vacancy, nil
+}
+
+// FindAllVacancy returns the open vacancies matching the filters.
+func (r *VacancyRepository) FindAllVacancy(filters model.Filters) ([]model.Vacancy, error) {
+	vacancies := []model.Vacancy{}
+	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(), vacancyFilter(filters))
 	if err != nil {
 		return nil, fmt.Errorf("cannot find vacancies: %w", err)
 	}
@@ -45,6 +498,18 @@ func (r *VacancyRepository) FindVacancyByTitle(title string) (*model.Vacancy, er
 	return &vacancy, nil
 }
 
+func (r *VacancyRepository) FindVacancyByLink(link string) (*model.Vacancy, error) {
+	var vacancy model.Vacancy
+	err := r.store.db.Collection("vacancies").FindOne(context.Background(), bson.D{{Key: "link", Value: link}}).Decode(&vacancy)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find vacancy: %w", err)
+	}
+	return &vacancy, nil
+}
+
 func (r *VacancyRepository) DeleteAllVacancy() (int64, error) {
 	result, err := r.store.db.Collection("vacancies").DeleteMany(context.Background(), bson.D{})
 	if err != nil {
@@ -54,10 +519,15 @@ func (r *VacancyRepository) DeleteAllVacancy() (int64, error) {
 	return result.DeletedCount, nil
 }
 
-func (r *VacancyRepository) GetVacancies(page, limit int64) ([]model.Vacancy, error) {
-	var vacancies []model.Vacancy
+// GetVacancies returns a page of the open vacancies matching the filters.
+func (r *VacancyRepository) GetVacancies(filters model.Filters, page, limit int64) ([]model.Vacancy, error) {
+	vacancies := []model.Vacancy{}
+	if page < 1 || limit < 1 {
+		return vacancies, nil
+	}
+
 	opts := options.Find().SetLimit(limit).SetSkip((page - 1) * limit)
-	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(), bson.D{}, opts)
+	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(), vacancyFilter(filters), opts)
 	if err != nil {
 		return nil, fmt.Errorf("cannot find vacancies: %w", err)
 	}
@@ -69,9 +539,8 @@ func (r *VacancyRepository) GetVacancies(page, limit int64) ([]model.Vacancy, er
 	return vacancies, nil
 }
 
-func (r *VacancyRepository) GetAllVacanciesCount() (int64, error) {
-	var count int64
-	count, err := r.store.db.Collection("vacancies").CountDocuments(context.Background(), bson.D{})
+func (r *VacancyRepository) GetAllVacanciesCount(filters model.Filters) (int64, error) {
+	count, err := r.store.db.Collection("vacancies").CountDocuments(context.Background(), vacancyFilter(filters))
 	if err != nil {
 		return 0, fmt.Errorf("cannot count vacancies: %w", err)
 	}
@@ -81,7 +550,7 @@ func (r *VacancyRepository) GetAllVacanciesCount() (int64, error) {
 func (r *VacancyRepository) GetAllHardSkills() ([]model.Vacancy, error) {
 	var skills []model.Vacancy // исправить потом на { hardskills: string[] }
 
-	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(), bson.D{{Key: "hardskills", Value: bson.D{{Key: "$exists", Value: true}}}}, options.Find().SetProjection(bson.D{{Key: "hardskills", Value: 1}}))
+	cursor, err := r.store.db.Collection("vacancies").Find(context.Background(), bson.D{{Key: "hardskills", Value: bson.D{{Key: "$exists", Value: true}}}, openVacancy}, options.Find().SetProjection(bson.D{{Key: "hardskills", Value: 1}}))
 	if err != nil {
 		return nil, fmt.Errorf("cannot find vacancies: %w", err)
 	}
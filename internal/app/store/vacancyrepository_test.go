@@ -0,0 +1,65 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVacancyRepository_FindFresh(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("vacancies")
+
+	link := "https://hh.ru/vacancy/1"
+	before := time.Now().UTC().Add(-time.Second)
+	_, err := s.Vacancy().UpsertVacancies([]*model.Vacancy{{Title: "Go developer", Link: link}})
+	require.NoError(t, err)
+
+	fresh, err := s.Vacancy().FindFresh([]string{link}, before)
+	require.NoError(t, err)
+	assert.True(t, fresh[link])
+
+	// Listing the vacancy again does not count as fetching it.
+	after := time.Now().UTC().Add(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, s.Vacancy().MarkSeen([]string{link}))
+
+	fresh, err = s.Vacancy().FindFresh([]string{link}, after)
+	require.NoError(t, err)
+	assert.False(t, fresh[link])
+
+	stored, err := s.Vacancy().FindVacancyByLink(link)
+	require.NoError(t, err)
+	assert.True(t, stored.LastSeenAt.After(stored.LastFetchedAt))
+}
+
+func TestVacancyRepository_UpsertVacancies(t *testing.T) {
+	s, teardown := store.TestStore(t, databaseURL)
+	defer teardown("vacancies")
+
+	link := "https://hh.ru/vacancy/1"
+	res, err := s.Vacancy().UpsertVacancies([]*model.Vacancy{{Title: "Go developer", Link: link}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Inserted)
+	assert.Equal(t, int64(0), res.Updated)
+
+	// Writing the same vacancy again does not count as an update.
+	res, err = s.Vacancy().UpsertVacancies([]*model.Vacancy{{Title: "Go developer", Link: link}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.Inserted)
+	assert.Equal(t, int64(0), res.Updated)
+	assert.Len(t, res.Written, 1)
+
+	res, err = s.Vacancy().UpsertVacancies([]*model.Vacancy{{Title: "Senior Go developer", Link: link}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Updated)
+	assert.Len(t, res.Changed, 1)
+
+	// The link identifies a vacancy, so it cannot be inserted twice.
+	_, err = s.Vacancy().InsertVacancy(&model.Vacancy{Title: "Go developer", Link: link})
+	assert.Error(t, err)
+}
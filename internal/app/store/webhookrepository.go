@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhooksCollection          = "webhooks"
+	webhookDeliveriesCollection = "webhook_deliveries"
+)
+
+type WebhookRepository struct {
+	store *Store
+}
+
+func (r *WebhookRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(webhooksCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+		{Keys: bson.D{{Key: "events", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create webhooks indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) Create(hook *model.Webhook) error {
+	if err := hook.Validate(); err != nil {
+		return err
+	}
+	hook.CreatedAt = time.Now().UTC()
+
+	result, err := r.store.db.Collection(webhooksCollection).InsertOne(context.Background(), hook)
+	if err != nil {
+		return fmt.Errorf("cannot create webhook: %w", err)
+	}
+
+	hook.ID = result.InsertedID.(primitive.ObjectID)
+
+	return nil
+}
+
+func (r *WebhookRepository) Find(id, ownerID primitive.ObjectID) (*model.Webhook, error) {
+	return r.findOne(bson.D{{Key: "_id", Value: id}, {Key: "owner_id", Value: ownerID}})
+}
+
+// FindByID returns the webhook regardless of its owner.
+func (r *WebhookRepository) FindByID(id primitive.ObjectID) (*model.Webhook, error) {
+	return r.findOne(bson.D{{Key: "_id", Value: id}})
+}
+
+func (r *WebhookRepository) findOne(filter bson.D) (*model.Webhook, error) {
+	var hook model.Webhook
+	err := r.store.db.Collection(webhooksCollection).FindOne(context.Background(), filter).Decode(&hook)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find webhook: %w", err)
+	}
+
+	return &hook, nil
+}
+
+func (r *WebhookRepository) FindByOwner(ownerID primitive.ObjectID) ([]model.Webhook, error) {
+	return r.find(bson.D{{Key: "owner_id", Value: ownerID}})
+}
+
+// FindSubscribed returns the webhooks subscribed to the event type.
+func (r *WebhookRepository) FindSubscribed(event string) ([]model.Webhook, error) {
+	return r.find(bson.D{{Key: "events", Value: event}})
+}
+
+func (r *WebhookRepository) find(filter bson.D) ([]model.Webhook, error) {
+	hooks := []model.Webhook{}
+	cursor, err := r.store.db.Collection(webhooksCollection).Find(context.Background(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find webhooks: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &hooks); err != nil {
+		return nil, fmt.Errorf("cannot decode webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+// Delete removes the webhook together with its delivery log.
+func (r *WebhookRepository) Delete(id, ownerID primitive.ObjectID) error {
+	result, err := r.store.db.Collection(webhooksCollection).DeleteOne(context.Background(), bson.D{
+		{Key: "_id", Value: id},
+		{Key: "owner_id", Value: ownerID},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delete webhook: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	if _, err := r.store.db.Collection(webhookDeliveriesCollection).DeleteMany(context.Background(), bson.D{{Key: "webhook_id", Value: id}}); err != nil {
+		return fmt.Errorf("cannot delete webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+type WebhookDeliveryRepository struct {
+	store *Store
+}
+
+func (r *WebhookDeliveryRepository) createIndexes(ctx context.Context) error {
+	_, err := r.store.db.Collection(webhookDeliveriesCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create webhook deliveries indexes: %w", err)
+	}
+
+	return nil
+}
+
+// Create stores new pending deliveries, due immediately.
+func (r *WebhookDeliveryRepository) Create(deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	docs := make([]interface{}, 0, len(deliveries))
+	for _, d := range deliveries {
+		d.ID = primitive.NewObjectID()
+		d.Status = model.DeliveryPending
+		d.NextAttemptAt = now
+		d.CreatedAt = now
+		d.UpdatedAt = now
+		docs = append(docs, d)
+	}
+
+	if _, err := r.store.db.Collection(webhookDeliveriesCollection).InsertMany(context.Background(), docs); err != nil {
+		return fmt.Errorf("cannot create webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) Find(id, webhookID primitive.ObjectID) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.store.db.Collection(webhookDeliveriesCollection).FindOne(context.Background(), bson.D{
+		{Key: "_id", Value: id},
+		{Key: "webhook_id", Value: webhookID},
+	}).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot find webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// FindByWebhook returns the latest deliveries of the webhook, newest first.
+func (r *WebhookDeliveryRepository) FindByWebhook(webhookID primitive.ObjectID, limit int64) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.store.db.Collection(webhookDeliveriesCollection).Find(context.Background(), bson.D{{Key: "webhook_id", Value: webhookID}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find webhook deliveries: %w", err)
+	}
+
+	if err := cursor.All(context.Background(), &deliveries); err != nil {
+		return nil, fmt.Errorf("cannot decode webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDue picks a pending delivery whose attempt is due and pushes its next
+// attempt lease into the future, so concurrent workers never send it twice.
+// ErrRecordNotFound is returned when nothing is due.
+func (r *WebhookDeliveryRepository) ClaimDue(lease time.Duration) (*model.WebhookDelivery, error) {
+	now := time.Now().UTC()
+
+	var delivery model.WebhookDelivery
+	err := r.store.db.Collection(webhookDeliveriesCollection).FindOneAndUpdate(context.Background(),
+		bson.D{
+			{Key: "status", Value: model.DeliveryPending},
+			{Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: now}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "next_attempt_at", Value: now.Add(lease)}}}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("cannot claim webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// UpdateAttempt records the outcome of a delivery attempt.
+func (r *WebhookDeliveryRepository) UpdateAttempt(delivery *model.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now().UTC()
+
+	_, err := r.store.db.Collection(webhookDeliveriesCollection).UpdateOne(context.Background(),
+		bson.D{{Key: "_id", Value: delivery.ID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: delivery.Status},
+			{Key: "attempts", Value: delivery.Attempts},
+			{Key: "response_status", Value: delivery.ResponseStatus},
+			{Key: "last_error", Value: delivery.LastError},
+			{Key: "next_attempt_at", Value: delivery.NextAttemptAt},
+			{Key: "updated_at", Value: delivery.UpdatedAt},
+		}}})
+	if err != nil {
+		return fmt.Errorf("cannot update webhook delivery: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const helpText = `Commands:
+/link CODE — link your account, get the code from POST /telegram/link
+/search go remote from 300k — find vacancies
+/subscribe go remote from 300k — get new matching vacancies here
+/subscriptions — list your subscriptions
+/unsubscribe N — remove subscription number N`
+
+// Bot answers commands from Telegram chats and pushes new saved search matches
+// to linked users.
+type Bot struct {
+	config *Config
+	client *Client
+	store  *store.Store
+}
+
+func NewBot(config *Config, store *store.Store) *Bot {
+	return &Bot{
+		config: config,
+		client: NewClient(config.BaseURL, config.Token, config.PollTimeout+10*time.Second),
+		store:  store,
+	}
+}
+
+// Run long polls the Bot API until ctx is done.
+func (b *Bot) Run(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := b.client.GetUpdates(ctx, offset, b.config.PollTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Println("cannot get telegram updates", err)
+				time.Sleep(5 * time.Second)
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message != nil {
+				b.handle(ctx, u.Message)
+			}
+		}
+	}
+}
+
+func (b *Bot) handle(ctx context.Context, msg *Message) {
+	command, args, _ := strings.Cut(strings.TrimSpace(msg.Text), " ")
+	command, _, _ = strings.Cut(command, "@")
+	args = strings.TrimSpace(args)
+
+	var reply string
+	var err error
+	switch command {
+	case "/start", "/link":
+		if args == "" {
+			reply = helpText
+			break
+		}
+		reply, err = b.link(msg.Chat.ID, args)
+	case "/search":
+		err = b.search(ctx, msg.Chat.ID, args)
+	case "/subscribe":
+		reply, err = b.subscribe(msg.Chat.ID, args)
+	case "/subscriptions":
+		reply, err = b.subscriptions(msg.Chat.ID)
+	case "/unsubscribe":
+		reply, err = b.unsubscribe(msg.Chat.ID, args)
+	default:
+		reply = helpText
+	}
+
+	if err != nil {
+		log.Println("cannot handle telegram command", command, err)
+		reply = "Something went wrong, please try again later."
+	}
+	if reply != "" {
+		b.send(ctx, msg.Chat.ID, reply)
+	}
+}
+
+func (b *Bot) link(chatID int64, code string) (string, error) {
+	user, err := b.store.User().LinkTelegram(strings.ToUpper(code), chatID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return "The code is invalid or expired.", nil
+		}
+		return "", err
+	}
+
+	return fmt.Sprintf("Linked to %s. Use /subscribe to get new vacancies here.", html.EscapeString(user.Email)), nil
+}
+
+func (b *Bot) search(ctx context.Context, chatID int64, args string) error {
+	filters := ParseFilters(args)
+	if filters.Empty() {
+		b.send(ctx, chatID, "Usage: /search go remote from 300k")
+		return nil
+	}
+
+	vacancies, err := b.store.Vacancy().GetVacancies(filters, 1, int64(b.config.SearchLimit))
+	if err != nil {
+		return err
+	}
+	for i := range vacancies {
+		b.send(ctx, chatID, FormatVacancy(&vacancies[i]))
+	}
+
+	if len(vacancies) == 0 {
+		b.send(ctx, chatID, "Nothing found.")
+		return nil
+	}
+	found, err := b.store.Vacancy().GetAllVacanciesCount(filters)
+	if err != nil {
+		return err
+	}
+	if found > int64(len(vacancies)) {
+		b.send(ctx, chatID, fmt.Sprintf("Shown %d of %d vacancies.", len(vacancies), found))
+	}
+
+	return nil
+}
+
+func (b *Bot) subscribe(chatID int64, args string) (string, error) {
+	user, reply, err := b.linkedUser(chatID)
+	if user == nil {
+		return reply, err
+	}
+
+	search := &model.SavedSearch{
+		UserID:  user.ID,
+		Name:    args,
+		Filters: ParseFilters(args),
+	}
+	if err := b.store.SavedSearch().Create(search); err != nil {
+		if errors.Is(err, model.ErrValidation) {
+			return "Usage: /subscribe go remote from 300k", nil
+		}
+		return "", err
+	}
+
+	return fmt.Sprintf("Subscribed to «%s».", html.EscapeString(args)), nil
+}
+
+func (b *Bot) subscriptions(chatID int64) (string, error) {
+	user, reply, err := b.linkedUser(chatID)
+	if user == nil {
+		return reply, err
+	}
+
+	searches, err := b.store.SavedSearch().FindByUser(user.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(searches) == 0 {
+		return "You have no subscriptions.", nil
+	}
+
+	var sb strings.Builder
+	for i, s := range searches {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, html.EscapeString(s.Name))
+	}
+
+	return sb.String(), nil
+}
+
+func (b *Bot) unsubscribe(chatID int64, args string) (string, error) {
+	user, reply, err := b.linkedUser(chatID)
+	if user == nil {
+		return reply, err
+	}
+
+	searches, err := b.store.SavedSearch().FindByUser(user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := strconv.Atoi(args)
+	if err != nil || n < 1 || n > len(searches) {
+		return "Usage: /unsubscribe N, see /subscriptions for the numbers.", nil
+	}
+
+	search := searches[n-1]
+	if err := b.store.SavedSearch().Delete(search.ID, user.ID); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Unsubscribed from «%s».", html.EscapeString(search.Name)), nil
+}
+
+// linkedUser returns the user linked to the chat, or the reply to send when there is none.
+func (b *Bot) linkedUser(chatID int64) (*model.User, string, error) {
+	user, err := b.store.User().FindByTelegramChatID(chatID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return nil, "Link your account first: /link CODE", nil
+		}
+		return nil, "", err
+	}
+
+	return user, "", nil
+}
+
+// NotifyMatches sends new saved search matches to the users who linked a chat.
+func (b *Bot) NotifyMatches(matches []model.SearchMatch) {
+	if len(matches) == 0 {
+		return
+	}
+
+	byUser := make(map[primitive.ObjectID][]model.SearchMatch)
+	ids := make([]primitive.ObjectID, 0)
+	for _, m := range matches {
+		if _, ok := byUser[m.UserID]; !ok {
+			ids = append(ids, m.UserID)
+		}
+		byUser[m.UserID] = append(byUser[m.UserID], m)
+	}
+
+	users, err := b.store.User().FindTelegramLinked(ids)
+	if err != nil {
+		log.Println("cannot find telegram users", err)
+		return
+	}
+
+	ctx := context.Background()
+	for _, user := range users {
+		for _, m := range byUser[user.ID] {
+			b.send(ctx, user.TelegramChatID, fmt.Sprintf("New vacancy for «%s»\n\n%s", html.EscapeString(m.SearchName), FormatVacancy(&m.Vacancy)))
+		}
+	}
+}
+
+func (b *Bot) send(ctx context.Context, chatID int64, text string) {
+	if err := b.client.SendMessage(ctx, chatID, text); err != nil {
+		log.Println("cannot send telegram message", chatID, err)
+	}
+}
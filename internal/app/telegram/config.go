@@ -0,0 +1,21 @@
+package telegram
+
+import "time"
+
+type Config struct {
+	Enabled bool   `toml:"enabled"`
+	Token   string `toml:"token"`
+	// BaseURL of the Bot API, can point to a local stub.
+	BaseURL     string        `toml:"base_url"`
+	PollTimeout time.Duration `toml:"poll_timeout"`
+	// SearchLimit is the number of vacancies returned by /search.
+	SearchLimit int `toml:"search_limit"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		BaseURL:     "https://api.telegram.org",
+		PollTimeout: 30 * time.Second,
+		SearchLimit: 5,
+	}
+}
@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"vacancy-parser/internal/app/model"
+)
+
+var salaryWordRe = regexp.MustCompile(`^(\d+)(k|к)?$`)
+
+// ParseFilters turns command arguments such as "go remote from 300k" into
+// filters. "remote"/"удаленно" sets Remote, "from N" or "от N" the minimal
+// salary, the other words are searched as text.
+func ParseFilters(args string) model.Filters {
+	var f model.Filters
+	var words []string
+
+	fields := strings.Fields(args)
+	for i := 0; i < len(fields); i++ {
+		word := strings.ToLower(fields[i])
+		switch word {
+		case "remote", "удаленно", "удалённо", "удаленка":
+			f.Remote = true
+			continue
+		case "from", "от":
+			if i+1 < len(fields) {
+				if salary, ok := parseSalaryWord(fields[i+1]); ok {
+					f.SalaryFrom = salary
+					i++
+					continue
+				}
+			}
+		}
+		words = append(words, fields[i])
+	}
+	f.Text = strings.Join(words, " ")
+
+	return f
+}
+
+func parseSalaryWord(word string) (int64, bool) {
+	m := salaryWordRe.FindStringSubmatch(strings.ToLower(word))
+	if m == nil {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[2] != "" {
+		n *= 1000
+	}
+
+	return n, true
+}
+
+// FormatVacancy renders a vacancy as an HTML Bot API message.
+func FormatVacancy(v *model.Vacancy) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<b>%s</b>\n", html.EscapeString(strings.TrimSpace(v.Title)))
+	if v.Company != "" {
+		fmt.Fprintf(&b, "%s\n", html.EscapeString(strings.TrimSpace(v.Company)))
+	}
+	if v.Salary != "" {
+		fmt.Fprintf(&b, "💰 %s\n", html.EscapeString(strings.TrimSpace(v.Salary)))
+	}
+	if v.Location != "" {
+		fmt.Fprintf(&b, "📍 %s\n", html.EscapeString(strings.TrimSpace(v.Location)))
+	}
+	if len(v.HardSkills) > 0 {
+		fmt.Fprintf(&b, "🛠 %s\n", html.EscapeString(strings.Join(v.HardSkills, ", ")))
+	}
+	fmt.Fprintf(&b, `<a href="%s">%s</a>`, html.EscapeString(v.Link), html.EscapeString(v.Link))
+
+	return b.String()
+}
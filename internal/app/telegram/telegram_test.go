@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilters(t *testing.T) {
+	testCases := []struct {
+		args    string
+		filters model.Filters
+	}{
+		{"go remote", model.Filters{Text: "go", Remote: true}},
+		{"Go удаленно от 300к", model.Filters{Text: "Go", Remote: true, SalaryFrom: 300000}},
+		{"golang from 250000", model.Filters{Text: "golang", SalaryFrom: 250000}},
+		{"from scratch", model.Filters{Text: "from scratch"}},
+		{"", model.Filters{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.args, func(t *testing.T) {
+			assert.Equal(t, tc.filters, ParseFilters(tc.args))
+		})
+	}
+}
+
+func TestFormatVacancy(t *testing.T) {
+	text := FormatVacancy(&model.Vacancy{
+		Title:      "Go <developer>",
+		Company:    "A & B",
+		Salary:     "от 300 000 ₽",
+		HardSkills: []string{"Go", "gRPC"},
+		Link:       "https://hh.ru/vacancy/1?a=1&b=2",
+	})
+
+	assert.Contains(t, text, "<b>Go &lt;developer&gt;</b>")
+	assert.Contains(t, text, "A &amp; B")
+	assert.Contains(t, text, "от 300 000 ₽")
+	assert.Contains(t, text, "Go, gRPC")
+	assert.Contains(t, text, `href="https://hh.ru/vacancy/1?a=1&amp;b=2"`)
+}
+
+func TestClient(t *testing.T) {
+	var sent map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bottoken/getUpdates":
+			io.WriteString(w, `{"ok":true,"result":[{"update_id":7,"message":{"message_id":1,"chat":{"id":42},"text":"/search go"}}]}`)
+		case "/bottoken/sendMessage":
+			json.NewDecoder(r.Body).Decode(&sent)
+			io.WriteString(w, `{"ok":true,"result":{}}`)
+		default:
+			io.WriteString(w, `{"ok":false,"description":"Not Found"}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL+"/", "token", time.Second)
+
+	updates, err := c.GetUpdates(context.Background(), 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, updates, 1)
+	assert.Equal(t, int64(7), updates[0].UpdateID)
+	assert.Equal(t, int64(42), updates[0].Message.Chat.ID)
+	assert.Equal(t, "/search go", updates[0].Message.Text)
+
+	assert.NoError(t, c.SendMessage(context.Background(), 42, "hello"))
+	assert.Equal(t, float64(42), sent["chat_id"])
+	assert.Equal(t, "HTML", sent["parse_mode"])
+
+	bad := NewClient(srv.URL, "other", time.Second)
+	assert.Error(t, bad.SendMessage(context.Background(), 42, "hello"))
+}
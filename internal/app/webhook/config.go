@@ -0,0 +1,31 @@
+package webhook
+
+import "time"
+
+type Config struct {
+	Enabled bool `toml:"enabled"`
+	// Workers is the number of deliveries sent concurrently.
+	Workers int           `toml:"workers"`
+	Timeout time.Duration `toml:"timeout"`
+	// MaxAttempts after which a delivery is marked failed.
+	MaxAttempts int `toml:"max_attempts"`
+	// The n-th retry waits InitialBackoff * 2^(n-1), at most MaxBackoff.
+	InitialBackoff time.Duration `toml:"initial_backoff"`
+	MaxBackoff     time.Duration `toml:"max_backoff"`
+	PollInterval   time.Duration `toml:"poll_interval"`
+	// AllowPrivate lets webhooks post to loopback and private network
+	// addresses, for local development only.
+	AllowPrivate bool `toml:"allow_private"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Enabled:        true,
+		Workers:        4,
+		Timeout:        10 * time.Second,
+		MaxAttempts:    8,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Hour,
+		PollInterval:   5 * time.Second,
+	}
+}
@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"vacancy-parser/internal/app/events"
+	"vacancy-parser/internal/app/model"
+	"vacancy-parser/internal/app/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	// deliveryLease is how long a claimed delivery stays hidden from other
+	// workers, it must exceed the request timeout.
+	deliveryLease = 2 * time.Minute
+)
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Dispatcher turns bus events into deliveries of subscribed webhooks and
+// sends them, retrying failed attempts with exponential backoff.
+type Dispatcher struct {
+	config *Config
+	store  *store.Store
+	client *http.Client
+	wake   chan struct{}
+}
+
+func NewDispatcher(config *Config, store *store.Store) *Dispatcher {
+	return &Dispatcher{
+		config: config,
+		store:  store,
+		client: newClient(config),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Run enqueues events published to the bus and sends due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, bus *events.Bus) {
+	ch, unsubscribe := bus.Subscribe(1024)
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case e := <-ch:
+			if err := d.Enqueue(e); err != nil {
+				log.Println("cannot enqueue webhook deliveries", e.Type, err)
+			}
+		}
+	}
+}
+
+// Enqueue creates a pending delivery of the event for every webhook subscribed to it.
+func (d *Dispatcher) Enqueue(e events.Event) error {
+	hooks, err := d.store.Webhook().FindSubscribed(e.Type)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	payload := Payload{ID: primitive.NewObjectID().Hex(), Type: e.Type, Time: e.Time}
+	if e.Vacancy != nil {
+		payload.Data = e.Vacancy
+	} else {
+		payload.Data = e.Job
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot encode webhook payload: %w", err)
+	}
+
+	var deliveries []*model.WebhookDelivery
+	for _, hook := range hooks {
+		if e.Vacancy != nil && hook.Filters != nil && !hook.Filters.Match(e.Vacancy) {
+			continue
+		}
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			WebhookID: hook.ID,
+			Event:     e.Type,
+			Payload:   string(body),
+		})
+	}
+
+	if err := d.store.WebhookDelivery().Create(deliveries); err != nil {
+		return err
+	}
+	d.notify()
+
+	return nil
+}
+
+// Replay queues a new delivery with the payload of an earlier one.
+func (d *Dispatcher) Replay(delivery *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	replayOf := delivery.ID
+	replay := &model.WebhookDelivery{
+		WebhookID: delivery.WebhookID,
+		Event:     delivery.Event,
+		Payload:   delivery.Payload,
+		ReplayOf:  &replayOf,
+	}
+	if err := d.store.WebhookDelivery().Create([]*model.WebhookDelivery{replay}); err != nil {
+		return nil, err
+	}
+	d.notify()
+
+	return replay, nil
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			delivery, err := d.store.WebhookDelivery().ClaimDue(deliveryLease)
+			if err != nil {
+				if !errors.Is(err, store.ErrRecordNotFound) {
+					log.Println("cannot claim webhook delivery", err)
+				}
+				break
+			}
+			d.attempt(ctx, delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery *model.WebhookDelivery) {
+	hook, err := d.store.Webhook().FindByID(delivery.WebhookID)
+	if err != nil {
+		if !errors.Is(err, store.ErrRecordNotFound) {
+			log.Println("cannot find webhook", err)
+			return
+		}
+		delivery.Status = model.DeliveryFailed
+		delivery.LastError = "webhook deleted"
+	} else {
+		delivery.Attempts++
+		delivery.ResponseStatus, err = Send(ctx, d.client, hook, delivery)
+		switch {
+		case err == nil:
+			delivery.Status = model.DeliverySucceeded
+			delivery.LastError = ""
+		case delivery.Attempts >= d.config.MaxAttempts:
+			delivery.Status = model.DeliveryFailed
+			delivery.LastError = err.Error()
+		default:
+			delivery.LastError = err.Error()
+			delivery.NextAttemptAt = time.Now().UTC().Add(Backoff(delivery.Attempts, d.config.InitialBackoff, d.config.MaxBackoff))
+		}
+	}
+
+	if err := d.store.WebhookDelivery().UpdateAttempt(delivery); err != nil {
+		log.Println("cannot record webhook delivery", err)
+	}
+}
+
+// Send posts the delivery payload to the webhook URL. Any response status
+// outside 2xx is an error.
+func Send(ctx context.Context, client *http.Client, hook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("cannot create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "vacancy-parser-webhook")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(SignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(hook.Secret, timestamp, body)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body" keyed with the webhook secret.
+// Receivers recompute it to verify the X-Webhook-Signature v1 value.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before the retry which follows the given attempt.
+func Backoff(attempt int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+
+	return delay
+}
+
+// NewSecret generates a webhook signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate webhook secret: %w", err)
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
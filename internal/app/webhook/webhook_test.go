@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"vacancy-parser/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strconv.Itoa(tc.attempt), func(t *testing.T) {
+			assert.Equal(t, tc.delay, Backoff(tc.attempt, 30*time.Second, time.Hour))
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	hook := &model.Webhook{Secret: "whsec_test"}
+	delivery := &model.WebhookDelivery{
+		ID:      primitive.NewObjectID(),
+		Event:   "vacancy.created",
+		Payload: `{"type":"vacancy.created"}`,
+	}
+
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, delivery.Payload, string(body))
+		assert.Equal(t, "vacancy.created", r.Header.Get(EventHeader))
+		assert.Equal(t, delivery.ID.Hex(), r.Header.Get(DeliveryHeader))
+
+		var timestamp int64
+		var signature string
+		_, err := fmt.Sscanf(strings.Replace(r.Header.Get(SignatureHeader), ",v1=", " ", 1), "t=%d %s", &timestamp, &signature)
+		assert.NoError(t, err)
+		assert.Equal(t, Sign(hook.Secret, timestamp, body), signature)
+
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	hook.URL = srv.URL
+
+	status = http.StatusNoContent
+	code, err := Send(context.Background(), srv.Client(), hook, delivery)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	status = http.StatusBadGateway
+	code, err = Send(context.Background(), srv.Client(), hook, delivery)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, code)
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, tc := range []struct {
+		url     string
+		private bool
+	}{
+		{"http://127.0.0.1:27017/", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://10.0.0.5/hook", true},
+		{"http://192.168.1.1/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"https://93.184.216.34/hook", false},
+	} {
+		err := CheckURL(context.Background(), tc.url)
+		if tc.private {
+			assert.ErrorIs(t, err, ErrPrivateAddress, tc.url)
+		} else {
+			assert.NoError(t, err, tc.url)
+		}
+	}
+}
+
+func TestSendRefusesPrivateAddress(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	hook := &model.Webhook{URL: srv.URL, Secret: "whsec_test"}
+	delivery := &model.WebhookDelivery{ID: primitive.NewObjectID(), Event: "vacancy.created", Payload: "{}"}
+
+	_, err := Send(context.Background(), newClient(NewConfig()), hook, delivery)
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+	assert.False(t, called)
+
+	config := NewConfig()
+	config.AllowPrivate = true
+	_, err = Send(context.Background(), newClient(config), hook, delivery)
+	assert.NoError(t, err)
+	assert.True(t, called)
+}